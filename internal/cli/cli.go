@@ -0,0 +1,205 @@
+// Package cli implements the go-sql-test command line.
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/askiada/go-sql-test/internal/parser"
+)
+
+const (
+	// ExitOK is returned when every test passed.
+	ExitOK = 0
+	// ExitFailure is returned when at least one test failed or a file is invalid.
+	ExitFailure = 1
+	// ExitError is returned when the command could not run at all.
+	ExitError = 2
+)
+
+const usage = `Usage: go-sql-test <command> [flags] <path>...
+
+A path is a SQL file, a directory searched recursively for .sql files, or a
+glob pattern where ** matches any number of directories (e.g. 'tests/**/*.sql').
+
+Commands:
+  run       run the tests of SQL files against a database
+  list      list the tests of SQL files
+  validate  check that SQL files are well formed, without a database
+
+Run "go-sql-test <command> -h" for the flags of a command.
+`
+
+// Main runs the command described by args and returns the process exit code.
+func Main(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		fmt.Fprintln(stderr, ErrNoCommand)
+
+		return ExitError
+	}
+
+	var cmd func(ctx context.Context, args []string, stdout, stderr io.Writer) (int, error)
+
+	switch args[0] {
+	case "run":
+		cmd = runCmd
+	case "list":
+		cmd = listCmd
+	case "validate":
+		cmd = validateCmd
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+
+		return ExitOK
+	default:
+		fmt.Fprint(stderr, usage)
+		fmt.Fprintf(stderr, "%s: %q\n", ErrUnknownCommand, args[0])
+
+		return ExitError
+	}
+
+	code, err := cmd(ctx, args[1:], stdout, stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return ExitOK
+	}
+
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: %s\n", args[0], err)
+	}
+
+	return code
+}
+
+func newFlagSet(name string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: go-sql-test %s [flags] <path>...\n\nFlags:\n", name)
+		fs.PrintDefaults()
+	}
+
+	return fs
+}
+
+func listCmd(_ context.Context, args []string, stdout, stderr io.Writer) (int, error) {
+	fs := newFlagSet("list", stderr)
+	filter := registerFilterFlags(fs)
+	file := fs.String("file", os.Getenv("SQL_FILE"), "SQL test file to list when no path is given (SQL_FILE)")
+
+	if err := fs.Parse(args); err != nil {
+		return ExitError, err //nolint:wrapcheck // the flag package already explains what went wrong
+	}
+
+	opts, err := filter.options()
+	if err != nil {
+		return ExitError, err
+	}
+
+	filenames, err := sqlFiles(fs, *file)
+	if err != nil {
+		return ExitError, err
+	}
+
+	out := strings.Builder{}
+
+	for _, filename := range filenames {
+		parsed, err := parser.ParseFile(filename)
+		if err != nil {
+			return ExitFailure, err //nolint:wrapcheck // the error already says where it comes from
+		}
+
+		for _, test := range parsed.Tests {
+			if !opts.Selects(test) {
+				continue
+			}
+
+			out.WriteString(fmt.Sprintf("%s #%d %s%s\t%s\n", parsed.Name, test.Index, test.DisplayName(), formatTags(test.Tags), firstLine(test.SQL)))
+		}
+	}
+
+	if _, err = io.WriteString(stdout, out.String()); err != nil {
+		return ExitError, fmt.Errorf("unable to write tests: %w", err)
+	}
+
+	return ExitOK, nil
+}
+
+func validateCmd(_ context.Context, args []string, stdout, stderr io.Writer) (int, error) {
+	fs := newFlagSet("validate", stderr)
+	file := fs.String("file", os.Getenv("SQL_FILE"), "SQL test file to validate when no path is given (SQL_FILE)")
+
+	if err := fs.Parse(args); err != nil {
+		return ExitError, err //nolint:wrapcheck // the flag package already explains what went wrong
+	}
+
+	filenames, err := sqlFiles(fs, *file)
+	if err != nil {
+		return ExitError, err
+	}
+
+	code := ExitOK
+
+	for _, filename := range filenames {
+		parsed, err := parser.ParseFile(filename)
+		if err != nil {
+			fmt.Fprintf(stdout, "FAIL %s\n", err)
+
+			code = ExitFailure
+
+			continue
+		}
+
+		fmt.Fprintf(stdout, "ok %s (%d tests)\n", parsed.Name, len(parsed.Tests))
+	}
+
+	return code, nil
+}
+
+// openOutput returns the writer for path, or stdout when path is empty or "-".
+// The returned function closes the file, if any.
+func openOutput(path string, stdout io.Writer) (io.Writer, func(), error) {
+	if path == "" || path == "-" {
+		return stdout, func() {}, nil
+	}
+
+	f, err := os.Create(filepath.Clean(path))
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to create output file: %w", err)
+	}
+
+	return f, func() { f.Close() }, nil //nolint:errcheck // we don't care about the error here
+}
+
+// formatTags returns the tags as they are written after START_TEST, with a leading space.
+func formatTags(tags []string) string {
+	res := strings.Builder{}
+
+	for _, tag := range tags {
+		res.WriteString(" @")
+		res.WriteString(tag)
+	}
+
+	return res.String()
+}
+
+// firstLine returns the first line of a statement that is neither blank nor a comment.
+func firstLine(sql string) string {
+	for _, line := range strings.Split(sql, "\n") {
+		line = strings.TrimSpace(line)
+
+		if line == "" || strings.HasPrefix(line, "--") || strings.HasPrefix(line, "/*") || strings.HasPrefix(line, "*/") {
+			continue
+		}
+
+		return line
+	}
+
+	return ""
+}
@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+	code := Main(context.Background(), []string{"validate", "../parser/testdata/2.sql"}, stdout, stderr)
+	require.Equal(t, ExitOK, code, stderr.String())
+	require.Equal(t, "ok ../parser/testdata/2.sql (2 tests)\n", stdout.String())
+}
+
+func TestList(t *testing.T) {
+	t.Parallel()
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+	code := Main(context.Background(), []string{"list", "-tags", "!slow", "../parser/testdata/1.sql", "../parser/testdata/4.sql"}, stdout, stderr)
+	require.Equal(t, ExitOK, code, stderr.String())
+	require.Equal(t, "../parser/testdata/1.sql #1 test_1\tSELECT COUNT(*)\n"+
+		"../parser/testdata/4.sql #1 orders_total @smoke\tSELECT COUNT(*) FROM orders\n"+
+		"../parser/testdata/4.sql #3 test_3\tSELECT COUNT(*) FROM refunds\n", stdout.String())
+}
+
+func TestRunInvalidRetries(t *testing.T) {
+	t.Parallel()
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+	code := Main(context.Background(), []string{
+		"run", "-host", "localhost", "-user", "postgres", "-dbname", "postgres", "-retries", "0", "../parser/testdata/1.sql",
+	}, stdout, stderr)
+	require.Equal(t, ExitError, code)
+	require.Contains(t, stderr.String(), ErrInvalidRetries.Error())
+}
+
+func TestUnknownCommand(t *testing.T) {
+	t.Parallel()
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+	code := Main(context.Background(), []string{"bogus"}, stdout, stderr)
+	require.Equal(t, ExitError, code)
+	require.Contains(t, stderr.String(), ErrUnknownCommand.Error())
+}
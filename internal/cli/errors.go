@@ -0,0 +1,28 @@
+package cli
+
+type usageError string
+
+func (s usageError) Error() string {
+	return string(s)
+}
+
+const (
+	// ErrUnknownCommand is returned when the sub-command does not exist.
+	ErrUnknownCommand = usageError("unknown command")
+	// ErrNoCommand is returned when no sub-command is given.
+	ErrNoCommand = usageError("no command given")
+	// ErrNoSQLFile is returned when no SQL file is given.
+	ErrNoSQLFile = usageError("no SQL file given, use -file, SQL_FILE or a positional argument")
+	// ErrNoSQLFileMatch is returned when a path, directory or pattern does not match any SQL file.
+	ErrNoSQLFileMatch = usageError("no SQL file found")
+	// ErrInvalidReport is returned when a -report flag is not kind=path.
+	ErrInvalidReport = usageError("invalid report, expected kind=path")
+	// ErrNoDBHost is returned when the database host is not set.
+	ErrNoDBHost = usageError("database host is not set, use -host or DB_HOST")
+	// ErrNoDBUser is returned when the database user is not set.
+	ErrNoDBUser = usageError("database user is not set, use -user or DB_USER")
+	// ErrNoDBName is returned when the database name is not set.
+	ErrNoDBName = usageError("database name is not set, use -dbname or DB_NAME")
+	// ErrInvalidRetries is returned when -retries would not run the queries at all.
+	ErrInvalidRetries = usageError("-retries must be at least 1")
+)
@@ -0,0 +1,174 @@
+package cli
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const sqlExt = ".sql"
+
+// collectFiles expands paths into the list of SQL files to use. A path can be
+// a file, a directory, walked recursively for .sql files, or a glob pattern
+// where ** matches any number of directories. Files are returned once, in
+// the order they are first found.
+func collectFiles(paths []string) ([]string, error) {
+	var (
+		files []string
+		seen  = make(map[string]struct{})
+	)
+
+	for _, p := range paths {
+		found, err := expandPath(p)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(found) == 0 {
+			return nil, fmt.Errorf("%w: %s", ErrNoSQLFileMatch, p)
+		}
+
+		for _, f := range found {
+			if _, ok := seen[f]; ok {
+				continue
+			}
+
+			seen[f] = struct{}{}
+
+			files = append(files, f)
+		}
+	}
+
+	return files, nil
+}
+
+func expandPath(p string) ([]string, error) {
+	if hasMeta(p) {
+		return glob(p)
+	}
+
+	info, err := os.Stat(p)
+	if err != nil {
+		return nil, fmt.Errorf("unable to stat path: %w", err)
+	}
+
+	if !info.IsDir() {
+		return []string{filepath.Clean(p)}, nil
+	}
+
+	var files []string
+
+	err = filepath.WalkDir(p, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.IsDir() && filepath.Ext(file) == sqlExt {
+			files = append(files, file)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to walk directory: %w", err)
+	}
+
+	slices.Sort(files)
+
+	return files, nil
+}
+
+// glob returns the files matching pattern. Unlike filepath.Glob, a ** segment
+// matches zero or more directories.
+func glob(pattern string) ([]string, error) {
+	pattern = filepath.ToSlash(filepath.Clean(pattern))
+	segments := strings.Split(pattern, "/")
+
+	// Walk from the deepest directory that has no pattern in it.
+	baseLen := 0
+	for baseLen < len(segments)-1 && !hasMeta(segments[baseLen]) {
+		baseLen++
+	}
+
+	base := strings.Join(segments[:baseLen], "/")
+	if base == "" && baseLen > 0 {
+		base = "/"
+	} else if base == "" {
+		base = "."
+	}
+
+	var files []string
+
+	err := filepath.WalkDir(base, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(base, file)
+		if err != nil {
+			return fmt.Errorf("unable to get relative path: %w", err)
+		}
+
+		ok, err := matchSegments(segments[baseLen:], strings.Split(filepath.ToSlash(rel), "/"))
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			files = append(files, file)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to glob %s: %w", pattern, err)
+	}
+
+	slices.Sort(files)
+
+	return files, nil
+}
+
+func matchSegments(pattern, name []string) (bool, error) {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				ok, err := matchSegments(pattern[1:], name[i:])
+				if err != nil || ok {
+					return ok, err
+				}
+			}
+
+			return false, nil
+		}
+
+		if len(name) == 0 {
+			return false, nil
+		}
+
+		ok, err := path.Match(pattern[0], name[0])
+		if err != nil {
+			return false, fmt.Errorf("invalid pattern: %w", err)
+		}
+
+		if !ok {
+			return false, nil
+		}
+
+		pattern, name = pattern[1:], name[1:]
+	}
+
+	return len(name) == 0, nil
+}
+
+func hasMeta(p string) bool {
+	return strings.ContainsAny(p, `*?[`)
+}
@@ -0,0 +1,30 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectFiles(t *testing.T) {
+	t.Parallel()
+
+	files, err := collectFiles([]string{
+		"../parser/testdata/2.sql",
+		"../**/testdata/[13].sql",
+		"../parser/testdata/3.sql",
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"../parser/testdata/2.sql",
+		"../parser/testdata/1.sql",
+		"../parser/testdata/3.sql",
+	}, files)
+}
+
+func TestCollectFilesNoMatch(t *testing.T) {
+	t.Parallel()
+
+	_, err := collectFiles([]string{"../**/nothing/*.sql"})
+	require.ErrorIs(t, err, ErrNoSQLFileMatch)
+}
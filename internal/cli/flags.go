@@ -0,0 +1,168 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/arsham/retry"
+
+	"github.com/askiada/go-sql-test/internal/model"
+	"github.com/askiada/go-sql-test/internal/parser"
+)
+
+// connFlags holds the flags used to connect to the database. They default to
+// the DB_* environment variables.
+type connFlags struct {
+	model.DBCredentials
+	maxConns   int
+	retries    int
+	retryDelay time.Duration
+}
+
+func registerConnFlags(fs *flag.FlagSet) *connFlags {
+	conn := &connFlags{}
+
+	port, _ := strconv.Atoi(os.Getenv("DB_PORT")) //nolint:errcheck // an invalid port falls back to 0 and is reported by the driver
+
+	fs.StringVar(&conn.Host, "host", os.Getenv("DB_HOST"), "database host (DB_HOST)")
+	fs.IntVar(&conn.Port, "port", port, "database port (DB_PORT)")
+	fs.StringVar(&conn.User, "user", os.Getenv("DB_USER"), "database user (DB_USER)")
+	fs.StringVar(&conn.Pass, "password", os.Getenv("DB_PASSWORD"), "database password (DB_PASSWORD)")
+	fs.StringVar(&conn.Name, "dbname", os.Getenv("DB_NAME"), "database name (DB_NAME)")
+	fs.IntVar(&conn.maxConns, "max-conns", 1, "maximum number of connections, 0 to use 90% of max_connections")
+	fs.IntVar(&conn.retries, "retries", 3, "number of attempts for each query") //nolint:mnd // same default as the tests
+	fs.DurationVar(&conn.retryDelay, "retry-delay", 100*time.Millisecond, "delay between two attempts")
+
+	return conn
+}
+
+func (c *connFlags) validate() error {
+	if c.Host == "" {
+		return ErrNoDBHost
+	}
+
+	if c.User == "" {
+		return ErrNoDBUser
+	}
+
+	if c.Name == "" {
+		return ErrNoDBName
+	}
+
+	// The retrier does not run a query at all without attempts, and reports
+	// no error.
+	if c.retries < 1 {
+		return ErrInvalidRetries
+	}
+
+	return nil
+}
+
+func (c *connFlags) retrier() retry.Retry {
+	return retry.Retry{
+		Method:   retry.IncrementalDelay,
+		Attempts: c.retries,
+		Delay:    c.retryDelay,
+	}
+}
+
+// sqlFiles returns the SQL files given as positional arguments or, when there
+// are none, with the -file flag.
+func sqlFiles(fs *flag.FlagSet, file string) ([]string, error) {
+	paths := fs.Args()
+	if len(paths) == 0 && file != "" {
+		paths = []string{file}
+	}
+
+	if len(paths) == 0 {
+		return nil, ErrNoSQLFile
+	}
+
+	return collectFiles(paths)
+}
+
+// filterFlags holds the flags used to select the tests to run.
+type filterFlags struct {
+	run  string
+	tags string
+}
+
+func registerFilterFlags(fs *flag.FlagSet) *filterFlags {
+	filter := &filterFlags{}
+
+	fs.StringVar(&filter.run, "run", "", "only select the tests whose name matches this regular expression")
+	fs.StringVar(&filter.tags, "tags", "", "only select the tests with one of these comma separated tags, and none of the tags prefixed with !")
+
+	return filter
+}
+
+func (f *filterFlags) options() (*parser.Options, error) {
+	opts := &parser.Options{
+		Tags: parser.ParseTags(f.tags),
+	}
+
+	if f.run != "" {
+		rgx, err := regexp.Compile(f.run)
+		if err != nil {
+			return nil, fmt.Errorf("invalid -run: %w", err)
+		}
+
+		opts.Run = rgx
+	}
+
+	return opts, nil
+}
+
+// registerIsolationFlag registers -isolation, to be parsed with
+// parser.ParseIsolation.
+func registerIsolationFlag(fs *flag.FlagSet) *string {
+	return fs.String("isolation", parser.IsolationNone.String(),
+		"roll back the changes of every test: none, transaction (one per test) or savepoint (one transaction per file, one savepoint per test)")
+}
+
+// isFlagSet reports whether the flag name was given on the command line.
+func isFlagSet(fs *flag.FlagSet, name string) bool {
+	found := false
+
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			found = true
+		}
+	})
+
+	return found
+}
+
+// reportFlag holds the -report flags, each one being kind=path.
+type reportFlag []reportSpec
+
+type reportSpec struct {
+	kind string
+	path string
+}
+
+func (r *reportFlag) String() string {
+	specs := make([]string, 0, len(*r))
+
+	for _, spec := range *r {
+		specs = append(specs, spec.kind+"="+spec.path)
+	}
+
+	return strings.Join(specs, ",")
+}
+
+func (r *reportFlag) Set(value string) error {
+	kind, path, ok := strings.Cut(value, "=")
+	if !ok || kind == "" || path == "" {
+		return fmt.Errorf("%w: %q", ErrInvalidReport, value)
+	}
+
+	*r = append(*r, reportSpec{kind: kind, path: path})
+
+	return nil
+}
@@ -0,0 +1,284 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/askiada/go-sql-test/internal/db"
+	"github.com/askiada/go-sql-test/internal/parser"
+	"github.com/askiada/go-sql-test/internal/report"
+)
+
+func runCmd(ctx context.Context, args []string, stdout, stderr io.Writer) (int, error) {
+	fs := newFlagSet("run", stderr)
+	conn := registerConnFlags(fs)
+	filter := registerFilterFlags(fs)
+	isolation := registerIsolationFlag(fs)
+	template := fs.String("template", "", "run every file in a new database copied from this template, and drop it afterwards")
+	parallel := fs.Int("parallel", 1, "maximum number of files run at the same time")
+	parallelTests := fs.Bool("parallel-tests", false, "also run the tests of a file at the same time, up to -parallel; they must be independent")
+	file := fs.String("file", os.Getenv("SQL_FILE"), "SQL test file to run when no path is given (SQL_FILE)")
+	output := fs.String("o", "", "write the results to this file instead of stdout")
+	failFast := fs.Bool("fail-fast", false, "stop at the first failure instead of running all the well formed tests and reporting every failure")
+	format := fs.String("format", "text", fmt.Sprintf("format of the results written to stdout or -o: %s", strings.Join(report.Kinds(), ", ")))
+
+	var reports reportFlag
+
+	fs.Var(&reports, "report", fmt.Sprintf("also write a report as kind=path, where kind is one of %s and path - is stdout; can be repeated",
+		strings.Join(report.Kinds(), ", ")))
+
+	if err := fs.Parse(args); err != nil {
+		return ExitError, err //nolint:wrapcheck // the flag package already explains what went wrong
+	}
+
+	opts, err := filter.options()
+	if err != nil {
+		return ExitError, err
+	}
+
+	if opts.Isolation, err = parser.ParseIsolation(*isolation); err != nil {
+		return ExitError, err //nolint:wrapcheck // the error already names the isolation
+	}
+
+	*parallel = max(1, *parallel)
+
+	if *parallelTests {
+		opts.Parallel = *parallel
+	}
+
+	opts.FailFast = *failFast
+
+	if err = opts.Validate(); err != nil {
+		return ExitError, err //nolint:wrapcheck // the error is explicit enough
+	}
+
+	// A throwaway database only serves the tests of one file.
+	throwawayMaxConns := conn.maxConns
+
+	if !isFlagSet(fs, "max-conns") {
+		conn.maxConns = max(conn.maxConns, *parallel)
+		throwawayMaxConns = max(1, opts.Parallel)
+	}
+
+	filenames, err := sqlFiles(fs, *file)
+	if err != nil {
+		return ExitError, err
+	}
+
+	if err = conn.validate(); err != nil {
+		return ExitError, err
+	}
+
+	client, err := db.NewClient(ctx, &conn.DBCredentials, conn.retrier(), conn.maxConns)
+	if err != nil {
+		return ExitError, fmt.Errorf("unable to connect to the database: %w", err)
+	}
+	defer client.Close()
+
+	reporters, closeReports, err := openReporters(reportSpec{kind: *format, path: *output}, reports, stdout)
+	defer closeReports()
+
+	if err != nil {
+		return ExitError, err
+	}
+
+	runner := &fileRunner{
+		client:            client,
+		conn:              conn,
+		template:          *template,
+		throwawayMaxConns: throwawayMaxConns,
+		opts:              opts,
+	}
+
+	code := ExitOK
+
+	results, err := runFiles(ctx, runner.run, filenames, *parallel, *failFast, func(fileRes *parser.FileResult) error {
+		if fileRes.Failed() {
+			code = ExitFailure
+		}
+
+		for _, reporter := range reporters {
+			if err := reporter.File(fileRes); err != nil {
+				return err //nolint:wrapcheck // the report already says what it was writing
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return ExitError, err
+	}
+
+	for _, reporter := range reporters {
+		if err = reporter.End(results); err != nil {
+			return ExitError, err //nolint:wrapcheck // the report already says what it was writing
+		}
+	}
+
+	if err = ctx.Err(); err != nil {
+		return ExitError, fmt.Errorf("interrupted: %w", err)
+	}
+
+	return code, nil
+}
+
+// openReporters returns the reporter of the main output, followed by the
+// reporters of the -report flags. The returned function closes their files.
+func openReporters(output reportSpec, reports reportFlag, stdout io.Writer) ([]report.Reporter, func(), error) {
+	var closers []func()
+
+	closeAll := func() {
+		for _, closeOut := range closers {
+			closeOut()
+		}
+	}
+
+	specs := append([]reportSpec{output}, reports...)
+	reporters := make([]report.Reporter, 0, len(specs))
+
+	for _, spec := range specs {
+		if !slices.Contains(report.Kinds(), spec.kind) {
+			return nil, closeAll, fmt.Errorf("%w: %q", report.ErrUnknownKind, spec.kind)
+		}
+
+		out, closeOut, err := openOutput(spec.path, stdout)
+		if err != nil {
+			return nil, closeAll, err
+		}
+
+		closers = append(closers, closeOut)
+
+		reporter, err := report.New(spec.kind, out)
+		if err != nil {
+			return nil, closeAll, err //nolint:wrapcheck // the error already names the kind
+		}
+
+		reporters = append(reporters, reporter)
+	}
+
+	return reporters, closeAll, nil
+}
+
+// fileRunner runs a file either against the shared client, or against a
+// database copied from template.
+type fileRunner struct {
+	client            *db.Client
+	conn              *connFlags
+	template          string
+	throwawayMaxConns int
+	opts              *parser.Options
+}
+
+func (r *fileRunner) run(ctx context.Context, filename string) *parser.FileResult {
+	if r.template == "" {
+		return parser.RunFile(ctx, filename, r.client.DBConnection, r.opts)
+	}
+
+	tdb, err := db.NewThrowawayDatabase(ctx, r.client, &r.conn.DBCredentials, r.template, r.conn.retrier(), r.throwawayMaxConns)
+	if err != nil {
+		return &parser.FileResult{File: &parser.File{Name: filename}, Err: err}
+	}
+
+	fileRes := parser.RunFile(ctx, filename, tdb.DBConnection, r.opts)
+
+	if err = tdb.Close(ctx); err != nil {
+		fileRes.Err = errors.Join(fileRes.Err, err)
+	}
+
+	return fileRes
+}
+
+// runFiles runs filenames with at most parallel of them at the same time.
+// done is called with the result of every file, in the order of filenames,
+// as soon as it and all the files before it are done. Once done fails, it is
+// not called anymore but the files still run to completion. With failFast,
+// the files after the first one that failed are not started, or cancelled,
+// and only the results passed to done are returned. Likewise, once ctx is
+// cancelled, the files that were not started yet are skipped.
+func runFiles(
+	ctx context.Context,
+	run func(ctx context.Context, filename string) *parser.FileResult,
+	filenames []string,
+	parallel int,
+	failFast bool,
+	done func(fileRes *parser.FileResult) error,
+) ([]*parser.FileResult, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]*parser.FileResult, len(filenames))
+	finished := make([]chan struct{}, len(filenames))
+
+	for i := range finished {
+		finished[i] = make(chan struct{})
+	}
+
+	// failed stops the files from being started as soon as one fails, while
+	// the running ones are only cancelled once the failure is reported.
+	var failed atomic.Bool
+
+	go func() {
+		sem := make(chan struct{}, parallel)
+
+		for i, filename := range filenames {
+			sem <- struct{}{}
+
+			if ctx.Err() != nil || failed.Load() {
+				close(finished[i])
+				<-sem
+
+				continue
+			}
+
+			go func() {
+				results[i] = run(ctx, filename)
+
+				if failFast && results[i].Failed() {
+					failed.Store(true)
+				}
+
+				close(finished[i])
+				<-sem
+			}()
+		}
+	}()
+
+	var err error
+
+	reported := len(filenames)
+
+	for i := range filenames {
+		<-finished[i]
+
+		if i >= reported {
+			continue
+		}
+
+		// The file was not started because ctx was cancelled.
+		if results[i] == nil {
+			reported = i
+
+			cancel()
+
+			continue
+		}
+
+		if err == nil {
+			err = done(results[i])
+		}
+
+		if failFast && results[i].Failed() {
+			reported = i + 1
+
+			cancel()
+		}
+	}
+
+	return results[:reported], err
+}
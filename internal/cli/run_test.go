@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/askiada/go-sql-test/internal/parser"
+)
+
+func TestRunFilesParallel(t *testing.T) {
+	t.Parallel()
+
+	var running, maxRunning atomic.Int32
+
+	run := func(_ context.Context, filename string) *parser.FileResult {
+		curr := running.Add(1)
+		defer running.Add(-1)
+
+		for {
+			prev := maxRunning.Load()
+			if curr <= prev || maxRunning.CompareAndSwap(prev, curr) {
+				break
+			}
+		}
+
+		// The first files are the slowest, so they finish last.
+		time.Sleep(time.Duration(10-len(filename)) * 5 * time.Millisecond)
+
+		return &parser.FileResult{File: &parser.File{Name: filename}}
+	}
+
+	filenames := []string{"a", "bb", "ccc", "dddd", "eeeee", "ffffff"}
+
+	var order []string
+
+	results, err := runFiles(context.Background(), run, filenames, 3, false, func(fileRes *parser.FileResult) error {
+		order = append(order, fileRes.File.Name)
+
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, filenames, order)
+	require.Len(t, results, len(filenames))
+	require.Equal(t, int32(3), maxRunning.Load())
+	require.Equal(t, "ffffff", results[5].File.Name)
+}
+
+func TestRunFilesFailFast(t *testing.T) {
+	t.Parallel()
+
+	var started atomic.Int32
+
+	run := func(_ context.Context, filename string) *parser.FileResult {
+		started.Add(1)
+
+		fileRes := &parser.FileResult{File: &parser.File{Name: filename}}
+		if filename == "b" {
+			fileRes.Err = parser.ErrIncompleteTest
+		}
+
+		return fileRes
+	}
+
+	var order []string
+
+	results, err := runFiles(context.Background(), run, []string{"a", "b", "c", "d"}, 1, true, func(fileRes *parser.FileResult) error {
+		order = append(order, fileRes.File.Name)
+
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b"}, order)
+	require.Len(t, results, 2)
+	require.LessOrEqual(t, started.Load(), int32(3))
+}
+
+func TestRunFilesCancelled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	run := func(ctx context.Context, filename string) *parser.FileResult {
+		if filename == "b" {
+			cancel()
+		}
+
+		return &parser.FileResult{File: &parser.File{Name: filename}, Err: ctx.Err()}
+	}
+
+	var order []string
+
+	results, err := runFiles(ctx, run, []string{"a", "b", "c", "d"}, 1, false, func(fileRes *parser.FileResult) error {
+		order = append(order, fileRes.File.Name)
+
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b"}, order)
+	require.Len(t, results, 2)
+	require.ErrorIs(t, results[1].Err, context.Canceled)
+}
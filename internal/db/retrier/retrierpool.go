@@ -30,7 +30,7 @@ func (r *Pool) Query(ctx context.Context, sql string, args ...interface{}) (pgx.
 		func() error {
 			rows, err = r.DBPool.Query(ctx, sql, args...) //nolint:sqlclosecheck // rows will be closed by the caller
 			if err != nil {
-				return enrichPgxError(err)
+				return stopOnPgError(enrichPgxError(err))
 			}
 
 			return nil
@@ -79,7 +79,7 @@ func (r *Pool) Exec(ctx context.Context, sql string, args ...interface{}) (pgcon
 		func() error {
 			commandTag, err = r.DBPool.Exec(ctx, sql, args...)
 			if err != nil {
-				return enrichPgxError(err)
+				return stopOnPgError(enrichPgxError(err))
 			}
 
 			return nil
@@ -119,6 +119,11 @@ func (r *Pool) BeginTransaction(ctx context.Context) (pgx.Tx, error) { //nolint:
 	return pgTx, nil
 }
 
+// Begin is the same as BeginTransaction. It makes the pool a model.TxBeginner.
+func (r *Pool) Begin(ctx context.Context) (pgx.Tx, error) { //nolint:ireturn // it's a wrapper
+	return r.BeginTransaction(ctx)
+}
+
 // CopyFrom executes a copy from.
 // It is not recommended to retry a copy from.
 func (r *Pool) CopyFrom(
@@ -136,6 +141,18 @@ func (r *Pool) CopyFrom(
 	return copied, nil
 }
 
+// stopOnPgError stops the retries when err was returned by the server for
+// the statement itself, e.g. a syntax error or a constraint violation, since
+// running it again would fail the same way.
+func stopOnPgError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return retry.StopError{Err: err}
+	}
+
+	return err
+}
+
 func enrichPgxError(err error) error {
 	var pgErr *pgconn.PgError
 	if errors.As(err, &pgErr) {
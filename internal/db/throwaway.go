@@ -0,0 +1,101 @@
+package db
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/arsham/retry"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/askiada/go-sql-test/internal/model"
+)
+
+// maxIdentifierLength is the maximum length of a postgres identifier.
+const maxIdentifierLength = 63
+
+// ThrowawayDatabase is a database created as a copy of a template, that is
+// dropped when it is closed.
+type ThrowawayDatabase struct {
+	*Client
+	admin *Client
+	name  string
+}
+
+// NewThrowawayDatabase creates a database as a copy of template through admin,
+// and connects to it with credentials. The template must not be the database
+// admin is connected to, as postgres cannot copy a database that is in use.
+func NewThrowawayDatabase(
+	ctx context.Context,
+	admin *Client,
+	credentials *model.DBCredentials,
+	template string,
+	retrier retry.Retry,
+	maxConns int,
+) (*ThrowawayDatabase, error) {
+	name, err := throwawayName(template)
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf("CREATE DATABASE %s TEMPLATE %s", pgx.Identifier{name}.Sanitize(), pgx.Identifier{template}.Sanitize())
+
+	_, err = admin.DBConnection.Exec(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create database from template %s: %w", template, err)
+	}
+
+	tdb := &ThrowawayDatabase{
+		admin: admin,
+		name:  name,
+	}
+
+	throwawayCredentials := *credentials
+	throwawayCredentials.Name = name
+
+	tdb.Client, err = NewClient(ctx, &throwawayCredentials, retrier, maxConns)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("unable to connect to %s: %w", name, err), tdb.drop(ctx))
+	}
+
+	return tdb, nil
+}
+
+// Name returns the name of the database.
+func (t *ThrowawayDatabase) Name() string {
+	return t.name
+}
+
+// Close terminates the connections and drops the database.
+func (t *ThrowawayDatabase) Close(ctx context.Context) error {
+	t.Client.Close()
+
+	return t.drop(ctx)
+}
+
+func (t *ThrowawayDatabase) drop(ctx context.Context) error {
+	_, err := t.admin.DBConnection.Exec(context.WithoutCancel(ctx), "DROP DATABASE IF EXISTS "+pgx.Identifier{t.name}.Sanitize())
+	if err != nil {
+		return fmt.Errorf("unable to drop database %s: %w", t.name, err)
+	}
+
+	return nil
+}
+
+// throwawayName returns a unique database name starting with template.
+func throwawayName(template string) (string, error) {
+	suffix := make([]byte, 8) //nolint:mnd // 16 hex characters are enough to avoid collisions
+
+	if _, err := rand.Read(suffix); err != nil {
+		return "", fmt.Errorf("unable to generate database name: %w", err)
+	}
+
+	name := "_" + hex.EncodeToString(suffix)
+	if len(template)+len(name) > maxIdentifierLength {
+		template = template[:maxIdentifierLength-len(name)]
+	}
+
+	return template + name, nil
+}
@@ -4,9 +4,18 @@ import (
 	"context"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 // DB is the interface that can perform database-related queries.
 type DB interface {
 	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+}
+
+// TxBeginner is a DB that can begin transactions. Within a transaction, Begin
+// creates a savepoint.
+type TxBeginner interface {
+	DB
+	Begin(ctx context.Context) (pgx.Tx, error)
 }
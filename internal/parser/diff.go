@@ -0,0 +1,460 @@
+package parser
+
+import (
+	"slices"
+	"strings"
+)
+
+// MismatchError is returned when the rows of a statement are not the expected
+// ones. Its message ends with a diff of both sides, see Diff.
+type MismatchError struct {
+	// Err is the reason of the mismatch, e.g. ErrRowsMismatch.
+	Err      error
+	Expected [][]string
+	Actual   [][]string
+	Ordered  bool
+}
+
+// Error implements the error interface.
+func (e *MismatchError) Error() string {
+	return e.Err.Error() + ":\n" + Diff(e.Expected, e.Actual, e.Ordered)
+}
+
+// Unwrap returns the reason of the mismatch.
+func (e *MismatchError) Unwrap() error {
+	return e.Err
+}
+
+type diffStatus int
+
+const (
+	diffStatusEqual diffStatus = iota
+	diffStatusChanged
+	diffStatusMissing
+	diffStatusExtra
+)
+
+// marker returns the character written in front of a line of the diff.
+func (s diffStatus) marker() string {
+	switch s {
+	case diffStatusChanged:
+		return "!"
+	case diffStatusMissing:
+		return "-"
+	case diffStatusExtra:
+		return "+"
+	default:
+		return " "
+	}
+}
+
+// diffLine is an expected row and the actual row it is compared with. One of
+// them is nil when the row is missing or extra.
+type diffLine struct {
+	status   diffStatus
+	expected []string
+	actual   []string
+}
+
+// Diff renders the expected and actual rows side by side as aligned tables.
+// Each line starts with a marker: "!" when the rows differ, in which case the
+// differing cells are wrapped in *, "-" when the expected row is missing and
+// "+" when the actual row is extra. When ordered is false, the rows are sorted
+// and every expected row is aligned with an actual row it matches, if any.
+// Keywords such as K_ANY match the actual value they are aligned with.
+func Diff(expected, actual [][]string, ordered bool) string {
+	var lines []diffLine
+	if ordered {
+		lines = alignOrdered(expected, actual)
+	} else {
+		lines = alignUnordered(expected, actual)
+	}
+
+	left := make([][]string, len(lines))
+	right := make([][]string, len(lines))
+
+	for i, line := range lines {
+		left[i], right[i] = highlightRow(line)
+	}
+
+	leftWidths := columnWidths(left)
+	rightWidths := columnWidths(right)
+
+	header := "expected"
+	if len(expected) == 0 {
+		header += " (no rows)"
+	}
+
+	leftWidth := max(tableWidth(leftWidths), len(header))
+
+	out := strings.Builder{}
+	out.WriteString("  ")
+	out.WriteString(pad(header, leftWidth))
+	out.WriteString(" | actual")
+
+	if len(actual) == 0 {
+		out.WriteString(" (no rows)")
+	}
+
+	out.WriteString("\n")
+
+	for i, line := range lines {
+		row := line.status.marker() + " " + pad(formatRow(left[i], leftWidths), leftWidth) + " | " + formatRow(right[i], rightWidths)
+
+		out.WriteString(strings.TrimRight(row, " "))
+		out.WriteString("\n")
+	}
+
+	return out.String()
+}
+
+func alignOrdered(expected, actual [][]string) []diffLine {
+	rows := compileRows(expected)
+	lines := make([]diffLine, 0, max(len(expected), len(actual)))
+
+	for i := range max(len(expected), len(actual)) {
+		switch {
+		case i >= len(actual):
+			lines = append(lines, diffLine{status: diffStatusMissing, expected: expected[i]})
+		case i >= len(expected):
+			lines = append(lines, diffLine{status: diffStatusExtra, actual: actual[i]})
+		default:
+			lines = append(lines, newDiffLine(rows[i], actual[i]))
+		}
+	}
+
+	return lines
+}
+
+// alignUnordered aligns as many expected rows as possible with an actual row
+// they match. The rows without matcher can only match the actual rows equal to
+// them, so they are paired first. The rows with a matcher are then aligned
+// with the remaining actual rows using a maximum bipartite matching, so that
+// a row matching several others, e.g. one of K_ANY, does not take the only
+// match of another. The rows left are aligned in sorted order as changed
+// rows, and the rest is missing or extra.
+func alignUnordered(expected, actual [][]string) []diffLine {
+	expected = cloneRows(expected)
+	actual = cloneRows(actual)
+
+	sortRows(expected)
+	sortRows(actual)
+
+	rows := compileRows(expected)
+	aligned := make([]int, len(expected))
+	owners := make([]int, len(actual))
+
+	for i := range aligned {
+		aligned[i] = -1
+	}
+
+	for j := range owners {
+		owners[j] = -1
+	}
+
+	// Both sides are sorted, so the equal rows are found in a single pass.
+	next := 0
+
+	for i, row := range rows {
+		if row.hasMatcher {
+			continue
+		}
+
+		for next < len(actual) && compareRows(actual[next], row.values) < 0 {
+			next++
+		}
+
+		if next < len(actual) && slices.Equal(actual[next], row.values) {
+			aligned[i] = next
+			owners[next] = i
+			next++
+		}
+	}
+
+	matching := &rowMatching{
+		rows:       rows,
+		actual:     actual,
+		aligned:    aligned,
+		owners:     owners,
+		fixed:      slices.Clone(owners),
+		candidates: make([][]int, len(rows)),
+	}
+
+	matching.run()
+
+	// The rows aligned so far match, the ones aligned below do not.
+	matched := slices.Clone(aligned)
+	used := make([]bool, len(actual))
+
+	for j := range owners {
+		used[j] = owners[j] != -1
+	}
+
+	next = 0
+
+	for i := range expected {
+		if aligned[i] != -1 {
+			continue
+		}
+
+		for next < len(actual) && used[next] {
+			next++
+		}
+
+		if next < len(actual) {
+			aligned[i] = next
+			used[next] = true
+		}
+	}
+
+	lines := make([]diffLine, 0, len(expected)+len(actual))
+
+	for i, row := range rows {
+		if aligned[i] == -1 {
+			lines = append(lines, diffLine{status: diffStatusMissing, expected: row.values})
+
+			continue
+		}
+
+		status := diffStatusChanged
+		if matched[i] != -1 {
+			status = diffStatusEqual
+		}
+
+		lines = append(lines, diffLine{status: status, expected: row.values, actual: actual[aligned[i]]})
+	}
+
+	for j, row := range actual {
+		if !used[j] {
+			lines = append(lines, diffLine{status: diffStatusExtra, actual: row})
+		}
+	}
+
+	return lines
+}
+
+// rowMatching aligns the expected rows with a matcher with the actual rows
+// they match, without moving the actual rows already aligned before, which
+// are told by fixed.
+type rowMatching struct {
+	rows   []expectedRow
+	actual [][]string
+	// aligned and owners are the matching in both directions, -1 meaning that
+	// the row is not aligned.
+	aligned []int
+	owners  []int
+	fixed   []int
+	// candidates are computed on demand, see candidatesOf.
+	candidates [][]int
+}
+
+// run first aligns every expected row with the first free actual row it
+// matches, which is enough most of the time. It then
+// looks for a maximum matching for the rows left, so that a row matching
+// several others, e.g. one of K_ANY, does not keep the only match of another.
+func (m *rowMatching) run() {
+	for i, row := range m.rows {
+		if !row.hasMatcher {
+			continue
+		}
+
+		if j := m.firstFree(row.matches); j != -1 {
+			m.align(i, j)
+		}
+	}
+
+	for i, row := range m.rows {
+		if row.hasMatcher && m.aligned[i] == -1 {
+			m.augment(i, make([]bool, len(m.actual)))
+		}
+	}
+}
+
+func (m *rowMatching) firstFree(matches func(actual []string) bool) int {
+	for j, actual := range m.actual {
+		if m.owners[j] == -1 && matches(actual) {
+			return j
+		}
+	}
+
+	return -1
+}
+
+func (m *rowMatching) align(i, j int) {
+	m.aligned[i] = j
+	m.owners[j] = i
+}
+
+// augment looks for an augmenting path starting at the expected row i, taking
+// the actual rows already aligned with another expected row when that one can
+// be aligned with another actual row. visited are the actual rows already
+// tried. It reports whether i was aligned.
+func (m *rowMatching) augment(i int, visited []bool) bool {
+	for _, j := range m.candidatesOf(i) {
+		if visited[j] {
+			continue
+		}
+
+		visited[j] = true
+
+		if m.owners[j] == -1 || m.augment(m.owners[j], visited) {
+			m.align(i, j)
+
+			return true
+		}
+	}
+
+	return false
+}
+
+// candidatesOf returns the indexes of the actual rows the expected row i
+// matches, but the fixed ones.
+func (m *rowMatching) candidatesOf(i int) []int {
+	if m.candidates[i] != nil {
+		return m.candidates[i]
+	}
+
+	candidates := []int{}
+
+	for j, actual := range m.actual {
+		if m.fixed[j] == -1 && m.rows[i].matches(actual) {
+			candidates = append(candidates, j)
+		}
+	}
+
+	m.candidates[i] = candidates
+
+	return candidates
+}
+
+func newDiffLine(expected expectedRow, actual []string) diffLine {
+	status := diffStatusEqual
+	if !expected.matches(actual) {
+		status = diffStatusChanged
+	}
+
+	return diffLine{status: status, expected: expected.values, actual: actual}
+}
+
+// expectedRow is an expected row whose cells are prepared once, see
+// compileCell.
+type expectedRow struct {
+	values     []string
+	cells      []cellMatcher
+	hasMatcher bool
+}
+
+// compileRows prepares the cells of rows, each distinct cell once.
+func compileRows(rows [][]string) []expectedRow {
+	compiled := make([]expectedRow, len(rows))
+	cells := map[string]cellMatcher{}
+
+	for i, row := range rows {
+		compiled[i].values = row
+		compiled[i].cells = make([]cellMatcher, len(row))
+
+		for j, value := range row {
+			cell, ok := cells[value]
+			if !ok {
+				cell = compileCell(value)
+				cells[value] = cell
+			}
+
+			compiled[i].cells[j] = cell
+			compiled[i].hasMatcher = compiled[i].hasMatcher || cell.isMatcher()
+		}
+	}
+
+	return compiled
+}
+
+// matches reports whether every cell of actual matches the expected one.
+func (r expectedRow) matches(actual []string) bool {
+	if len(r.cells) != len(actual) {
+		return false
+	}
+
+	if !r.hasMatcher {
+		return slices.Equal(r.values, actual)
+	}
+
+	for i, cell := range r.cells {
+		if cell.check(actual[i]) != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// highlightRow returns the cells of both rows of line, with the cells that do
+// not match wrapped in *.
+func highlightRow(line diffLine) ([]string, []string) {
+	left := displayRow(line.expected)
+	right := displayRow(line.actual)
+
+	if line.status != diffStatusChanged {
+		return left, right
+	}
+
+	for i := range max(len(left), len(right)) {
+		if i < len(left) && i < len(right) && cellMatches(line.expected[i], line.actual[i]) {
+			continue
+		}
+
+		if i < len(left) {
+			left[i] = "*" + left[i] + "*"
+		}
+
+		if i < len(right) {
+			right[i] = "*" + right[i] + "*"
+		}
+	}
+
+	return left, right
+}
+
+func columnWidths(rows [][]string) []int {
+	var widths []int
+
+	for _, row := range rows {
+		for i, cell := range row {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+
+			widths[i] = max(widths[i], len([]rune(cell)))
+		}
+	}
+
+	return widths
+}
+
+// tableWidth returns the width of a row formatted with widths.
+func tableWidth(widths []int) int {
+	width := 0
+
+	for i, w := range widths {
+		if i > 0 {
+			width += len(" | ")
+		}
+
+		width += w
+	}
+
+	return width
+}
+
+func formatRow(row []string, widths []int) string {
+	cells := make([]string, len(row))
+
+	for i, cell := range row {
+		cells[i] = pad(cell, widths[i])
+	}
+
+	return strings.Join(cells, " | ")
+}
+
+func pad(s string, width int) string {
+	return s + strings.Repeat(" ", max(width-len([]rune(s)), 0))
+}
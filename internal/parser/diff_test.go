@@ -0,0 +1,62 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		expected [][]string
+		actual   [][]string
+		ordered  bool
+		diff     string
+	}{
+		"unordered": {
+			expected: [][]string{{"3", "carol", "30"}, {"1", "alice", "K_ANY"}, {"2", "bob", "20"}},
+			actual:   [][]string{{"4", "dave", "40"}, {"2", "bob", "25"}, {"1", "alice", "10"}},
+			diff: `  expected              | actual
+  1   | alice   | K_ANY | 1   | alice  | 10
+! 2   | bob     | *20*  | 2   | bob    | *25*
+! *3* | *carol* | *30*  | *4* | *dave* | *40*
+`,
+		},
+		"ordered": {
+			expected: [][]string{{"1"}, {"2"}, {"3"}},
+			actual:   [][]string{{"2"}, {"1"}},
+			ordered:  true,
+			diff: `  expected | actual
+! *1*      | *2*
+! *2*      | *1*
+- 3        |
+`,
+		},
+		"null": {
+			expected: [][]string{{"1", "K_NULL"}, {"2", ""}},
+			actual:   [][]string{{"1", "K_NULL"}, {"2", NullValue}},
+			ordered:  true,
+			diff: `  expected     | actual
+! 1 | *K_NULL* | 1 | *K_NULL*
+! 2 | **       | 2 | *∅*
+`,
+		},
+		"extra rows": {
+			expected: nil,
+			actual:   [][]string{{"1", ""}},
+			diff: `  expected (no rows) | actual
++                    | 1 |
+`,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tc.diff, Diff(tc.expected, tc.actual, tc.ordered))
+		})
+	}
+}
@@ -53,6 +53,24 @@ const (
 	ErrUnexpectedStatement = runError("unexpected statement")
 	// ErrUnexpectedGroupType is returned when an unexpected group type is found.
 	ErrUnexpectedGroupType = runError("unexpected group type")
+	// ErrIncompleteTest is returned when a file ends with a statement without instructions, or the other way around.
+	ErrIncompleteTest = runError("incomplete test at end of file")
+	// ErrInvalidSQLState is returned when an ERROR instruction does not start with a SQLSTATE code.
+	ErrInvalidSQLState = runError("invalid SQLSTATE")
+	// ErrNoError is returned when a statement succeeds but an ERROR instruction expects it to fail.
+	ErrNoError = runError("statement succeeded but was expected to fail")
+	// ErrUnexpectedError is returned when a statement does not fail with the expected error.
+	ErrUnexpectedError = runError("statement failed with an unexpected error")
+	// ErrUnknownIsolation is returned when an isolation mode does not exist.
+	ErrUnknownIsolation = runError("unknown isolation")
+	// ErrNoTransaction is returned when the tests must be isolated but the database cannot begin transactions.
+	ErrNoTransaction = runError("database does not support transactions")
+	// ErrParallelSavepoint is returned when tests should run in parallel within the single transaction of a file.
+	ErrParallelSavepoint = runError("tests cannot run in parallel with savepoint isolation")
+	// ErrInvalidMatcherName is returned when a matcher is registered with a name that cannot be written as K_<NAME>.
+	ErrInvalidMatcherName = runError("invalid matcher name")
+	// ErrMatcherExists is returned when a matcher is registered with the name of another one.
+	ErrMatcherExists = runError("matcher already registered")
 )
 
 type sortError string
@@ -66,6 +84,48 @@ const (
 	ErrDifferentRowCount = sortError("different row count")
 	// ErrDifferentColumnCount is returned when the actual and expected column counts differ.
 	ErrDifferentColumnCount = sortError("different column count")
-	// ErrAnyNotNullButEmpty is returned when the actual value is empty but the expected value is not.
-	ErrAnyNotNullButEmpty = sortError("ANY_NOT_NULL but empty")
+	// ErrAnyNotNullButEmpty is returned when the actual value is NULL but the expected value is K_ANY_NOT_NULL.
+	ErrAnyNotNullButEmpty = sortError("ANY_NOT_NULL but NULL")
+	// ErrRowsMismatch is returned when the actual rows are not the expected ones.
+	ErrRowsMismatch = sortError("actual rows do not match expected rows")
+	// ErrRowOutOfPlace is returned when an ORDERED test gets its rows in a different order.
+	ErrRowOutOfPlace = sortError("rows do not match in order")
+	// ErrRowsAffectedMismatch is returned when a statement does not affect the expected number of rows.
+	ErrRowsAffectedMismatch = sortError("rows affected do not match")
+	// ErrCommandTagMismatch is returned when a statement does not return the expected command tag.
+	ErrCommandTagMismatch = sortError("command tag does not match")
+	// ErrCellMismatch is returned when the actual value of a cell is not the expected one.
+	ErrCellMismatch = sortError("cell does not match")
+	// ErrUnknownMatcher is returned when an expected cell uses a K_<NAME>(...) matcher that does not exist.
+	ErrUnknownMatcher = sortError("unknown matcher")
+	// ErrInvalidMatcher is returned when the arguments of a matcher are invalid.
+	ErrInvalidMatcher = sortError("invalid matcher")
+	// ErrNotNull is returned when the actual value is not NULL but the expected value is K_NULL.
+	ErrNotNull = sortError("value is not NULL")
+	// ErrNull is returned when a matcher that expects a value gets a NULL.
+	ErrNull = sortError("value is NULL")
+	// ErrNoPatternMatch is returned when a value does not match the pattern of K_REGEX, K_LIKE or K_ILIKE.
+	ErrNoPatternMatch = sortError("value does not match the pattern")
+	// ErrInvalidJSON is returned when a JSON matcher gets a value that is not a JSON document.
+	ErrInvalidJSON = sortError("invalid JSON")
+	// ErrJSONMismatch is returned when a value is not the JSON document of K_JSON.
+	ErrJSONMismatch = sortError("JSON documents differ")
+	// ErrJSONNotContained is returned when a value does not contain the JSON document of K_JSON_CONTAINS.
+	ErrJSONNotContained = sortError("JSON document is not contained")
+	// ErrNotATimestamp is returned when a time matcher gets a value that is not a date or a timestamp.
+	ErrNotATimestamp = sortError("not a timestamp")
+	// ErrOutsideWindow is returned when a timestamp is not in the window of a time matcher.
+	ErrOutsideWindow = sortError("timestamp is outside the window")
+	// ErrNotAUUID is returned when K_UUID gets a value that is not a UUID.
+	ErrNotAUUID = sortError("not a UUID")
+	// ErrNotAnInt is returned when K_INT gets a value that is not an integer.
+	ErrNotAnInt = sortError("not an integer")
+	// ErrNotABool is returned when K_BOOL gets a value that is not a boolean.
+	ErrNotABool = sortError("not a boolean")
+	// ErrOutOfRange is returned when a number does not meet a condition of K_INT or K_NUMERIC.
+	ErrOutOfRange = sortError("number is out of range")
+	// ErrNotANumber is returned when a numeric matcher gets a value that is not a number.
+	ErrNotANumber = sortError("not a number")
+	// ErrNotApprox is returned when a number is not within the tolerance of K_APPROX.
+	ErrNotApprox = sortError("number is not within tolerance")
 )
@@ -0,0 +1,59 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ExpectedAffected describes the outcome of a statement run through Exec. It
+// is built from an AFFECTED instruction, followed either by the number of
+// rows affected, by the command tag, or by both:
+//
+//	AFFECTED 3
+//	AFFECTED INSERT 0 3
+//	AFFECTED 3 INSERT 0 3
+type ExpectedAffected struct {
+	Rows int64
+	// Tag is the expected command tag. It is not checked when empty.
+	Tag string
+}
+
+func extractAffected(content string) (*ExpectedAffected, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return nil, fmt.Errorf("empty content")
+	}
+
+	first, rest, _ := strings.Cut(content, " ")
+
+	rows, err := strconv.ParseInt(first, 10, 64)
+	if err == nil {
+		return &ExpectedAffected{
+			Rows: rows,
+			Tag:  strings.TrimSpace(rest),
+		}, nil
+	}
+
+	tag := pgconn.NewCommandTag(content)
+
+	return &ExpectedAffected{
+		Rows: tag.RowsAffected(),
+		Tag:  content,
+	}, nil
+}
+
+// check returns nil if tag matches the expected outcome.
+func (e *ExpectedAffected) check(tag pgconn.CommandTag) error {
+	if tag.RowsAffected() != e.Rows {
+		return fmt.Errorf("%w: expected %d, got %d (%s)", ErrRowsAffectedMismatch, e.Rows, tag.RowsAffected(), tag)
+	}
+
+	if e.Tag != "" && tag.String() != e.Tag {
+		return fmt.Errorf("%w: expected %q, got %q", ErrCommandTagMismatch, e.Tag, tag)
+	}
+
+	return nil
+}
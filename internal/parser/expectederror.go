@@ -0,0 +1,124 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ExpectedError describes how the statement of a test must fail. It is built
+// from an ERROR instruction:
+//
+//	ERROR <sqlstate> [field=value]... [message regex]
+//
+// A two characters SQLSTATE matches the whole class, e.g. 23 for integrity
+// constraint violations. The fields are schema, table, column, datatype and
+// constraint.
+type ExpectedError struct {
+	Code    string
+	Fields  map[string]string
+	Message *regexp.Regexp
+}
+
+var rgxSQLState = regexp.MustCompile(`^[0-9A-Z]{2}([0-9A-Z]{3})?$`)
+
+func pgErrorFields(pgErr *pgconn.PgError) map[string]string {
+	return map[string]string{
+		"schema":     pgErr.SchemaName,
+		"table":      pgErr.TableName,
+		"column":     pgErr.ColumnName,
+		"datatype":   pgErr.DataTypeName,
+		"constraint": pgErr.ConstraintName,
+	}
+}
+
+func extractError(content string) (*ExpectedError, error) {
+	content = strings.TrimSpace(content)
+
+	code, rest, _ := strings.Cut(content, " ")
+	if !rgxSQLState.MatchString(code) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidSQLState, code)
+	}
+
+	expErr := &ExpectedError{
+		Code:   code,
+		Fields: make(map[string]string),
+	}
+
+	known := pgErrorFields(&pgconn.PgError{})
+
+	for {
+		rest = strings.TrimSpace(rest)
+
+		word, next, _ := strings.Cut(rest, " ")
+
+		key, value, ok := strings.Cut(word, "=")
+		if _, isField := known[key]; !ok || !isField {
+			break
+		}
+
+		expErr.Fields[key] = value
+		rest = next
+	}
+
+	if rest != "" {
+		rgx, err := regexp.Compile(rest)
+		if err != nil {
+			return nil, fmt.Errorf("invalid message regular expression: %w", err)
+		}
+
+		expErr.Message = rgx
+	}
+
+	return expErr, nil
+}
+
+// check returns nil if err is the expected error.
+func (e *ExpectedError) check(err error) error {
+	if err == nil {
+		return fmt.Errorf("%w: %s", ErrNoError, e)
+	}
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return fmt.Errorf("%w: expected %s, got: %w", ErrUnexpectedError, e, err)
+	}
+
+	if !strings.HasPrefix(pgErr.Code, e.Code) {
+		return fmt.Errorf("%w: expected %s, got: %w", ErrUnexpectedError, e, err)
+	}
+
+	actualFields := pgErrorFields(pgErr)
+
+	for key, value := range e.Fields {
+		if actualFields[key] != value {
+			return fmt.Errorf("%w: expected %s=%s, got %s=%s: %w", ErrUnexpectedError, key, value, key, actualFields[key], err)
+		}
+	}
+
+	if e.Message != nil && !e.Message.MatchString(pgErr.Message) {
+		return fmt.Errorf("%w: expected message matching %q, got: %w", ErrUnexpectedError, e.Message, err)
+	}
+
+	return nil
+}
+
+// String returns the error as it is written in an ERROR instruction.
+func (e *ExpectedError) String() string {
+	parts := []string{e.Code}
+
+	for _, key := range []string{"schema", "table", "column", "datatype", "constraint"} {
+		if value, ok := e.Fields[key]; ok {
+			parts = append(parts, key+"="+value)
+		}
+	}
+
+	if e.Message != nil {
+		parts = append(parts, e.Message.String())
+	}
+
+	return "ERROR " + strings.Join(parts, " ")
+}
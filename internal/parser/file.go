@@ -0,0 +1,245 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// File is a parsed SQL test file.
+type File struct {
+	Name  string
+	Tests []*Test
+	// Setup and Teardown are run once, before the first test and after the
+	// last one.
+	Setup    []*Fixture
+	Teardown []*Fixture
+	// BeforeEach and AfterEach are run around every test.
+	BeforeEach []*Fixture
+	AfterEach  []*Fixture
+}
+
+// Fixture is a statement run through Exec around the tests of a file, without
+// any expectation. It is declared by a SETUP, TEARDOWN, BEFORE_EACH or
+// AFTER_EACH instruction.
+type Fixture struct {
+	// Kind is the instruction that declared the fixture, e.g. SETUP.
+	Kind string
+	// Line is the first line of the fixture, from its instructions.
+	Line int
+	SQL  string
+}
+
+func (f *File) addFixture(kind instructionPrefix, line int, sql string) {
+	fixture := &Fixture{
+		Kind: kind.String(),
+		Line: line,
+		SQL:  sql,
+	}
+
+	switch kind {
+	case instructionPrefixSetup:
+		f.Setup = append(f.Setup, fixture)
+	case instructionPrefixTeardown:
+		f.Teardown = append(f.Teardown, fixture)
+	case instructionPrefixBeforeEach:
+		f.BeforeEach = append(f.BeforeEach, fixture)
+	case instructionPrefixAfterEach:
+		f.AfterEach = append(f.AfterEach, fixture)
+	default:
+	}
+}
+
+// Test is a statement paired with the output it is expected to return.
+type Test struct {
+	// Index is the 1-based position of the test in its file.
+	Index int
+	// StartLine and EndLine are the first and last lines of the test, from
+	// its instructions to its statement.
+	StartLine int
+	EndLine   int
+	// Name is the text following START_TEST, if any.
+	Name string
+	// Tags are the @tag annotations following START_TEST, without the @.
+	Tags     []string
+	SQL      string
+	Expected [][]string
+	// Ordered is set by the ORDERED instruction: the rows are compared in the
+	// order they are returned instead of being sorted first.
+	Ordered bool
+	// Error is set by the ERROR instruction: the statement must fail with
+	// this error and Expected is empty.
+	Error *ExpectedError
+	// Affected is set by the AFFECTED instruction: the statement is run
+	// through Exec and Expected is empty.
+	Affected *ExpectedAffected
+}
+
+// Mode returns how the outcome of the statement is compared: unordered,
+// ordered, error or affected.
+func (t *Test) Mode() string {
+	switch {
+	case t.Error != nil:
+		return "error"
+	case t.Affected != nil:
+		return "affected"
+	case t.Ordered:
+		return "ordered"
+	default:
+		return "unordered"
+	}
+}
+
+// hasInstructions reports whether the instructions of the test have been parsed.
+func (t *Test) hasInstructions() bool {
+	return t.Expected != nil || t.Error != nil || t.Affected != nil
+}
+
+// DisplayName returns the name of the test, or test_<index> when it has none.
+func (t *Test) DisplayName() string {
+	if t.Name != "" {
+		return t.Name
+	}
+
+	return fmt.Sprintf("test_%d", t.Index)
+}
+
+// ParseFile reads filename and pairs every statement with its instructions.
+// Statements paired with a fixture instruction are kept apart from the tests.
+// It does not need a database, so it can be used to validate or list tests.
+//
+// The mistakes found in the file are returned together, each as a *LineError.
+// Unless the file cannot be split into groups at all, the returned File still
+// holds the tests that are well formed, so that they can be run anyway. A
+// broken test keeps its index, so the following tests are numbered the same
+// once it is fixed.
+func ParseFile(filename string) (*File, error) {
+	lines, err := parseFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrParseFile, err)
+	}
+
+	groups, err := getGroups(lines)
+	if err != nil {
+		return nil, atFile(filename, fmt.Errorf("unable to get groups: %w", err))
+	}
+
+	file := &File{
+		Name: filename,
+	}
+
+	var errs []error
+
+	curr := &Test{}
+	// fixture is set when the current instructions declare a fixture.
+	fixture := instructionPrefixUnknown
+	// broken is set when the current instructions are invalid: the test is
+	// dropped once its statement is found.
+	broken := false
+	index := 0
+
+	// restart drops the current test, which is incomplete, and starts a new
+	// one with group.
+	restart := func(group *groupLines) {
+		curr = &Test{}
+		curr.addLines(group.lines)
+		fixture = instructionPrefixUnknown
+		broken = false
+	}
+
+	for _, group := range groups {
+		curr.addLines(group.lines)
+
+		switch group._type {
+		case groupTypeInstructions:
+			if curr.hasInstructions() || fixture != instructionPrefixUnknown || broken {
+				errs = append(errs, atFile(filename, atLine(group.lines[0].number, ErrUnexpectedInstruction)))
+
+				restart(group)
+			}
+
+			instr, err := getInstructions(group.lines)
+			if err != nil {
+				errs = append(errs, atFile(filename, fmt.Errorf("unable to get instructions: %w", err)))
+				broken = true
+
+				break
+			}
+
+			if instr._type.isFixture() {
+				fixture = instr._type
+
+				break
+			}
+
+			curr.Expected = instr.values
+			curr.Name = instr.name
+			curr.Tags = instr.tags
+			curr.Ordered = instr.ordered
+			curr.Error = instr.expectedErr
+			curr.Affected = instr.affected
+
+		case groupTypeStatement:
+			if curr.SQL != "" {
+				errs = append(errs, atFile(filename, atLine(group.lines[0].number, ErrUnexpectedStatement)))
+
+				restart(group)
+			}
+
+			curr.SQL = buildQuery(group.lines)
+
+		case groupTypeUnknown:
+			errs = append(errs, atFile(filename, atLine(group.lines[0].number, ErrUnexpectedGroupType)))
+		}
+
+		switch {
+		case curr.SQL == "":
+		case broken:
+			index++
+			curr = &Test{}
+			broken = false
+		case fixture != instructionPrefixUnknown:
+			file.addFixture(fixture, curr.StartLine, curr.SQL)
+			curr = &Test{}
+			fixture = instructionPrefixUnknown
+		case curr.hasInstructions():
+			index++
+			curr.Index = index
+			file.Tests = append(file.Tests, curr)
+			curr = &Test{}
+		}
+	}
+
+	if curr.hasInstructions() || curr.SQL != "" || fixture != instructionPrefixUnknown || broken {
+		errs = append(errs, atFile(filename, atLine(curr.StartLine, ErrIncompleteTest)))
+	}
+
+	return file, errors.Join(errs...)
+}
+
+// addLines extends the line range of the test to lines, ignoring the trailing
+// blank ones.
+func (t *Test) addLines(lines []parsedLine) {
+	for _, line := range lines {
+		if strings.TrimSpace(line.line) == "" {
+			continue
+		}
+
+		if t.StartLine == 0 {
+			t.StartLine = line.number
+		}
+
+		t.EndLine = line.number
+	}
+}
+
+func buildQuery(lines []parsedLine) string {
+	query := strings.Builder{}
+
+	for _, line := range lines {
+		query.WriteString(line.line)
+		query.WriteString("\n")
+	}
+
+	return query.String()
+}
@@ -0,0 +1,171 @@
+package parser
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFileNamesAndTags(t *testing.T) {
+	t.Parallel()
+
+	file, err := ParseFile("testdata/4.sql")
+	require.NoError(t, err)
+	require.Len(t, file.Tests, 3)
+
+	require.Equal(t, "orders_total", file.Tests[0].DisplayName())
+	require.Equal(t, []string{"smoke"}, file.Tests[0].Tags)
+	require.Equal(t, [][]string{{"3"}}, file.Tests[0].Expected)
+
+	require.Equal(t, "orders_by_customer", file.Tests[1].DisplayName())
+	require.Equal(t, []string{"slow", "reporting"}, file.Tests[1].Tags)
+	require.Equal(t, [][]string{{"alice", "2"}, {"bob", "1"}}, file.Tests[1].Expected)
+
+	require.Equal(t, "test_3", file.Tests[2].DisplayName())
+	require.Empty(t, file.Tests[2].Tags)
+
+	lines := make([][2]int, 0, len(file.Tests))
+	for _, test := range file.Tests {
+		lines = append(lines, [2]int{test.StartLine, test.EndLine})
+	}
+
+	require.Equal(t, [][2]int{{1, 6}, {8, 12}, {14, 17}}, lines)
+}
+
+func TestGetInstructionsCombined(t *testing.T) {
+	t.Parallel()
+
+	_, err := getInstructions([]parsedLine{
+		parseLine("-- START_TEST"),
+		parseLine("-- ROW 1"),
+		parseLine("-- ERROR 23505"),
+		parseLine("-- COUNT 1"),
+		parseLine("-- END_TEST"),
+	})
+	require.ErrorContains(t, err, "can't have both ROW, COUNT and ERROR instructions")
+}
+
+func TestParseFileLineErrors(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		content string
+		err     string
+	}{
+		"duplicate instruction": {
+			content: "-- START_TEST\n-- COUNT 1\n-- COUNT 2\n-- END_TEST\nSELECT 1\n",
+			err:     ":3: unable to get instructions: duplicate instruction: COUNT",
+		},
+		"unexpected start": {
+			content: "-- START_TEST\n/*\nROW 1\n-- START_TEST\n",
+			err:     ":4: unable to get groups: unexpected start of group inside instructions group",
+		},
+		"incomplete test": {
+			content: "-- START_TEST\n-- COUNT 1\n-- END_TEST\nSELECT 1\n\n-- START_TEST\n-- COUNT 1\n-- END_TEST\n",
+			err:     ":6: incomplete test at end of file",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			filename := filepath.Join(t.TempDir(), "test.sql")
+			require.NoError(t, os.WriteFile(filename, []byte(tc.content), 0o600))
+
+			_, err := ParseFile(filename)
+
+			var lineErr *LineError
+			require.ErrorAs(t, err, &lineErr)
+			require.Equal(t, filename+tc.err, err.Error())
+		})
+	}
+}
+
+func TestParseFileReadError(t *testing.T) {
+	t.Parallel()
+
+	content := "-- START_TEST\n-- COUNT 1\n-- END_TEST\nSELECT '" + strings.Repeat("a", bufio.MaxScanTokenSize) + "'\n"
+
+	filename := filepath.Join(t.TempDir(), "test.sql")
+	require.NoError(t, os.WriteFile(filename, []byte(content), 0o600))
+
+	_, err := ParseFile(filename)
+	require.ErrorIs(t, err, ErrParseFile)
+	require.ErrorIs(t, err, bufio.ErrTooLong)
+
+	var lineErr *LineError
+	require.ErrorAs(t, err, &lineErr)
+	require.Equal(t, filename, lineErr.File)
+	require.Equal(t, 4, lineErr.Line)
+}
+
+func TestParseFileCollectsErrors(t *testing.T) {
+	t.Parallel()
+
+	content := `-- START_TEST first
+-- COUNT 1
+-- COUNT 2
+-- END_TEST
+SELECT 1
+
+-- START_TEST second
+-- COUNT 1
+-- END_TEST
+SELECT 1
+
+-- START_TEST third
+-- ROW 1
+-- ERROR 23505
+-- END_TEST
+SELECT 1
+
+-- START_TEST fourth
+-- COUNT 1
+-- END_TEST
+SELECT 1
+`
+
+	filename := filepath.Join(t.TempDir(), "test.sql")
+	require.NoError(t, os.WriteFile(filename, []byte(content), 0o600))
+
+	file, err := ParseFile(filename)
+	require.EqualError(t, err, filename+":3: unable to get instructions: duplicate instruction: COUNT\n"+
+		filename+":12: unable to get instructions: error checking combined instructions: can't have both ROW and ERROR instructions")
+
+	require.Len(t, file.Tests, 2)
+	require.Equal(t, "second", file.Tests[0].Name)
+	require.Equal(t, 2, file.Tests[0].Index)
+	require.Equal(t, "fourth", file.Tests[1].Name)
+	require.Equal(t, 4, file.Tests[1].Index)
+}
+
+func TestExtractFileNullToken(t *testing.T) {
+	t.Parallel()
+
+	filename := filepath.Join(t.TempDir(), "expected.csv")
+	require.NoError(t, os.WriteFile(filename, []byte("1,\\N,\n2,,\"\"\n"), 0o600))
+
+	testCases := map[string]struct {
+		content  string
+		expected [][]string
+	}{
+		"no token":     {content: filename, expected: [][]string{{"1", `\N`, ""}, {"2", "", ""}}},
+		"token":        {content: filename + ` NULL \N`, expected: [][]string{{"1", "K_NULL", ""}, {"2", "", ""}}},
+		"quoted token": {content: filename + ` NULL ""`, expected: [][]string{{"1", `\N`, "K_NULL"}, {"2", "K_NULL", "K_NULL"}}},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			rows, err := extractFile(tc.content)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, rows)
+		})
+	}
+}
@@ -60,7 +60,7 @@ func getGroups(lines []parsedLine) ([]*groupLines, error) {
 			case lineTypeUnknown:
 				group = append(group, line)
 			case lineTypeStartTest:
-				return nil, ErrInstructionsUnexpectedStart
+				return nil, atLine(line.number, ErrInstructionsUnexpectedStart)
 			case lineTypeEndTest:
 				group = append(group, line)
 				gl := &groupLines{
@@ -86,7 +86,7 @@ func getGroups(lines []parsedLine) ([]*groupLines, error) {
 				group = []parsedLine{line}
 				nextGroupType = groupTypeInstructions
 			case lineTypeEndTest:
-				return nil, ErrsStatementUnexpectedEnd
+				return nil, atLine(line.number, ErrsStatementUnexpectedEnd)
 			case lineTypeComment:
 				group = append(group, line)
 			}
@@ -0,0 +1,41 @@
+package parser
+
+import "fmt"
+
+// Isolation tells how the tests are isolated from each other.
+type Isolation int
+
+const (
+	// IsolationNone runs the statements as they are, so every test sees the
+	// changes of the ones before.
+	IsolationNone Isolation = iota
+	// IsolationTransaction runs every test, with its BEFORE_EACH and
+	// AFTER_EACH fixtures, in a transaction that is rolled back.
+	IsolationTransaction
+	// IsolationSavepoint runs the whole file in a transaction that is rolled
+	// back after the TEARDOWN fixtures, and every test in a savepoint that is
+	// rolled back after it.
+	IsolationSavepoint
+)
+
+func (i Isolation) String() string {
+	switch i {
+	case IsolationTransaction:
+		return "transaction"
+	case IsolationSavepoint:
+		return "savepoint"
+	default:
+		return "none"
+	}
+}
+
+// ParseIsolation returns the isolation named s.
+func ParseIsolation(s string) (Isolation, error) {
+	for _, isolation := range []Isolation{IsolationNone, IsolationTransaction, IsolationSavepoint} {
+		if isolation.String() == s {
+			return isolation, nil
+		}
+	}
+
+	return IsolationNone, fmt.Errorf("%w: %q", ErrUnknownIsolation, s)
+}
@@ -1,42 +1,35 @@
 package parser
 
+// Keyword is the expected value of a cell written by the parser itself.
 type Keyword string
 
-const (
-	KeywordAny        Keyword = "K_ANY"
-	KeywordAnyNotNull Keyword = "K_ANY_NOT_NULL"
-)
+// KeywordNull only matches a NULL. It is distinct from the empty string.
+const KeywordNull Keyword = "K_NULL"
 
-func replaceKeywords(actual, expected [][]string) ([][]string, [][]string, error) {
-	if len(actual) != len(expected) {
-		return nil, nil, ErrDifferentRowCount
+func matchAny(_ string, args []string) error {
+	return checkArgs(args, 0, 0)
+}
+
+func matchAnyNotNull(actual string, args []string) error {
+	if err := checkArgs(args, 0, 0); err != nil {
+		return err
+	}
+
+	if actual == NullValue {
+		return ErrAnyNotNullButEmpty
+	}
+
+	return nil
+}
+
+func matchNull(actual string, args []string) error {
+	if err := checkArgs(args, 0, 0); err != nil {
+		return err
 	}
 
-	for i := range actual {
-		if len(actual[i]) != len(expected[i]) {
-			return nil, nil, ErrDifferentColumnCount
-		}
-
-		for j := range actual[i] {
-			if expected[i][j] == string(KeywordAny) {
-				actual[i][j] = string(KeywordAny)
-			} else if expected[i][j] == string(KeywordAnyNotNull) {
-				if actual[i][j] == "" {
-					return nil, nil, ErrAnyNotNullButEmpty
-				}
-
-				if actual[i][j] == "null" {
-					return nil, nil, ErrAnyNotNullButEmpty
-				}
-
-				if actual[i][j] == "NULL" {
-					return nil, nil, ErrAnyNotNullButEmpty
-				}
-
-				actual[i][j] = string(KeywordAnyNotNull)
-			}
-		}
+	if actual != NullValue {
+		return ErrNotNull
 	}
 
-	return actual, expected, nil
+	return nil
 }
@@ -0,0 +1,61 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+)
+
+// LineError is an error located in a SQL test file. It is written as
+// file.sql:42: message, so that editors can jump to it.
+type LineError struct {
+	File string
+	// Line is the 1-based line number, or 0 when the error concerns the whole file.
+	Line int
+	Err  error
+}
+
+// Error implements the error interface.
+func (e *LineError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("%s: %s", e.File, e.Err)
+	}
+
+	return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Err)
+}
+
+// Unwrap returns the located error.
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// lineError records the line an error comes from until the name of the file
+// is known, see atFile.
+type lineError struct {
+	line int
+	err  error
+}
+
+func (e *lineError) Error() string {
+	return e.err.Error()
+}
+
+func (e *lineError) Unwrap() error {
+	return e.err
+}
+
+func atLine(line int, err error) error {
+	return &lineError{line: line, err: err}
+}
+
+// atFile returns err as a *LineError of filename, at the line recorded by
+// atLine, if any.
+func atFile(filename string, err error) error {
+	located := &LineError{File: filename, Err: err}
+
+	var lineErr *lineError
+	if errors.As(err, &lineErr) {
+		located.Line = lineErr.line
+	}
+
+	return located
+}
@@ -0,0 +1,359 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// MatchFunc checks the actual value of a cell against a K_<NAME>(args)
+// expected cell, and returns an error explaining why it does not match.
+// actual is the rendered value, NullValue for a NULL. args are the trimmed
+// arguments between the parentheses, split on the commas that are neither
+// quoted nor nested in brackets; an argument between single quotes is
+// unquoted.
+type MatchFunc func(actual string, args []string) error
+
+// builtinMatchers are the K_<NAME> keywords an expected cell can hold, by
+// name, in addition to the ones of customMatchers. A matcher without arguments
+// can be written without parentheses, e.g. K_ANY.
+var builtinMatchers = map[string]MatchFunc{
+	"ANY":              matchAny,
+	"ANY_NOT_NULL":     matchAnyNotNull,
+	"NULL":             matchNull,
+	"APPROX":           matchApprox,
+	"REGEX":            matchRegex,
+	"LIKE":             matchLike,
+	"ILIKE":            matchILike,
+	"JSON":             matchJSON,
+	"JSON_CONTAINS":    matchJSONContains,
+	"NOW":              matchNow,
+	"TIMESTAMP_AFTER":  matchTimestampAfter,
+	"TIMESTAMP_BEFORE": matchTimestampBefore,
+	"DATE_TODAY":       matchDateToday,
+	"UUID":             matchUUID,
+	"INT":              matchInt,
+	"NUMERIC":          matchNumeric,
+	"BOOL":             matchBool,
+}
+
+// matcherCompiler prepares the arguments of a matcher once, e.g. compiles its
+// pattern, and returns the function checking the actual values.
+type matcherCompiler func(args []string) (func(actual string) error, error)
+
+// matcherCompilers are the built-in matchers that are costly to prepare, so
+// that an expected cell is prepared once rather than for every actual value,
+// see compileCell.
+var matcherCompilers = map[string]matcherCompiler{
+	"REGEX": compileRegex,
+	"LIKE":  compileLike,
+	"ILIKE": compileILike,
+}
+
+var (
+	customMatchersMu sync.RWMutex
+	// customMatchers are the matchers added by RegisterMatcher.
+	customMatchers = map[string]MatchFunc{}
+)
+
+var rgxMatcherName = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+
+// RegisterMatcher makes K_<name>(args) usable in the expected cells of ROW,
+// FILE and COUNT instructions, checked by match. name may be given with its
+// K_ prefix. It returns an error if name is not made of upper case letters,
+// digits and underscores, or is already registered, including by a built-in
+// matcher such as K_ANY.
+func RegisterMatcher(name string, match MatchFunc) error {
+	name = strings.TrimPrefix(name, "K_")
+
+	if !rgxMatcherName.MatchString(name) {
+		return fmt.Errorf("%w: %q", ErrInvalidMatcherName, name)
+	}
+
+	customMatchersMu.Lock()
+	defer customMatchersMu.Unlock()
+
+	if _, ok := builtinMatchers[name]; ok {
+		return fmt.Errorf("%w: K_%s", ErrMatcherExists, name)
+	}
+
+	if _, ok := customMatchers[name]; ok {
+		return fmt.Errorf("%w: K_%s", ErrMatcherExists, name)
+	}
+
+	customMatchers[name] = match
+
+	return nil
+}
+
+// UnregisterMatcher removes a matcher added by RegisterMatcher. It is meant
+// for tests, so that they can run several times in the same process. The
+// built-in matchers cannot be removed.
+func UnregisterMatcher(name string) {
+	name = strings.TrimPrefix(name, "K_")
+
+	customMatchersMu.Lock()
+	defer customMatchersMu.Unlock()
+
+	delete(customMatchers, name)
+}
+
+func lookupMatcher(name string) (MatchFunc, bool) {
+	if match, ok := builtinMatchers[name]; ok {
+		return match, true
+	}
+
+	customMatchersMu.RLock()
+	defer customMatchersMu.RUnlock()
+
+	match, ok := customMatchers[name]
+
+	return match, ok
+}
+
+var rgxMatcher = regexp.MustCompile(`^K_([A-Z][A-Z0-9_]*)(?:\((.*)\))?$`)
+
+// parseMatcher splits an expected cell such as K_APPROX(3.14, 0.001) into the
+// name of its matcher and its arguments. ok is false when the cell is a plain
+// value, including a word starting with K_ that is not a matcher.
+func parseMatcher(cell string) (string, []string, bool) {
+	matches := rgxMatcher.FindStringSubmatch(strings.TrimSpace(cell))
+	if matches == nil {
+		return "", nil, false
+	}
+
+	name := matches[1]
+
+	if !strings.HasSuffix(matches[0], ")") {
+		_, ok := lookupMatcher(name)
+
+		return name, nil, ok
+	}
+
+	return name, splitArgs(matches[2]), true
+}
+
+// mayBeMatcher reports whether cell may be a matcher, see parseMatcher, which
+// is cheaper to check.
+func mayBeMatcher(cell string) bool {
+	return strings.HasPrefix(strings.TrimSpace(cell), "K_")
+}
+
+// matchCell returns an error when the actual value of a cell does not match
+// the expected one, which may be a matcher.
+func matchCell(expected, actual string) error {
+	return compileCell(expected).check(actual)
+}
+
+// cellMatcher is an expected cell whose matcher, if any, is parsed and
+// prepared once, to check many actual values.
+type cellMatcher struct {
+	expected string
+	// match checks an actual value against the matcher of expected. It is nil
+	// when expected is a plain value.
+	match func(actual string) error
+	// err is set when the matcher of expected does not exist.
+	err error
+}
+
+func compileCell(expected string) cellMatcher {
+	cell := cellMatcher{expected: expected}
+
+	name, args, ok := parseMatcher(expected)
+	if !ok {
+		return cell
+	}
+
+	if compile, ok := matcherCompilers[name]; ok {
+		match, err := compile(args)
+		if err != nil {
+			match = func(string) error { return err }
+		}
+
+		cell.match = match
+
+		return cell
+	}
+
+	match, ok := lookupMatcher(name)
+	if !ok {
+		cell.err = fmt.Errorf("%w: K_%s", ErrUnknownMatcher, name)
+
+		return cell
+	}
+
+	cell.match = func(actual string) error {
+		return match(actual, args)
+	}
+
+	return cell
+}
+
+func (c cellMatcher) check(actual string) error {
+	if c.err != nil {
+		return c.err
+	}
+
+	if c.match == nil {
+		if c.expected != actual {
+			return fmt.Errorf("%w: %q is not %q", ErrCellMismatch, actual, c.expected)
+		}
+
+		return nil
+	}
+
+	if err := c.match(actual); err != nil {
+		return fmt.Errorf("%s does not match %s: %w", strings.TrimSpace(c.expected), describeValue(actual), err)
+	}
+
+	return nil
+}
+
+// isMatcher reports whether the cell is not a plain value.
+func (c cellMatcher) isMatcher() bool {
+	return c.match != nil || c.err != nil
+}
+
+// matchCompiled checks actual with the matcher prepared by compile from args.
+func matchCompiled(compile matcherCompiler, actual string, args []string) error {
+	match, err := compile(args)
+	if err != nil {
+		return err
+	}
+
+	return match(actual)
+}
+
+// checkMatchers returns an error when a cell of rows uses a matcher that does
+// not exist.
+func checkMatchers(rows [][]string) error {
+	for _, row := range rows {
+		for _, cell := range row {
+			name, _, ok := parseMatcher(cell)
+			if !ok {
+				continue
+			}
+
+			if _, ok := lookupMatcher(name); !ok {
+				return fmt.Errorf("%w: K_%s", ErrUnknownMatcher, name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// cellMatches reports whether the actual value of a cell matches the expected
+// one, which may be a matcher.
+func cellMatches(expected, actual string) bool {
+	return matchCell(expected, actual) == nil
+}
+
+// checkArgs returns an error when the number of arguments of a matcher is not
+// between minArgs and maxArgs.
+func checkArgs(args []string, minArgs, maxArgs int) error {
+	if len(args) < minArgs || len(args) > maxArgs {
+		if minArgs == maxArgs {
+			return fmt.Errorf("%w: expected %d arguments, got %d", ErrInvalidMatcher, minArgs, len(args))
+		}
+
+		return fmt.Errorf("%w: expected %d to %d arguments, got %d", ErrInvalidMatcher, minArgs, maxArgs, len(args))
+	}
+
+	return nil
+}
+
+// splitArgs splits the arguments of a matcher on the commas that are neither
+// quoted nor nested in brackets, and trims them. An argument between single
+// quotes is unquoted, where two single quotes stand for one, so that it can
+// hold commas or spaces as they are.
+func splitArgs(s string) []string {
+	if strings.TrimSpace(s) == "" {
+		return nil
+	}
+
+	var (
+		args  []string
+		depth int
+		quote rune
+		start int
+	)
+
+	runes := []rune(s)
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		switch {
+		case r == '\\':
+			i++
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == '(' || r == '[' || r == '{':
+			depth++
+		case r == ')' || r == ']' || r == '}':
+			depth--
+		case r == ',' && depth == 0:
+			args = append(args, unquoteArg(string(runes[start:i])))
+			start = i + 1
+		}
+	}
+
+	return append(args, unquoteArg(string(runes[start:])))
+}
+
+func unquoteArg(arg string) string {
+	arg = strings.TrimSpace(arg)
+
+	if len(arg) >= 2 && arg[0] == '\'' && arg[len(arg)-1] == '\'' {
+		return strings.ReplaceAll(arg[1:len(arg)-1], "''", "'")
+	}
+
+	return arg
+}
+
+// matcherEnd returns the index following the closing parenthesis of the
+// matcher starting s, such as K_APPROX(3.14, 0.001), or -1 if s does not start
+// with a matcher with arguments.
+func matcherEnd(s string) int {
+	loc := rgxMatcherStart.FindStringIndex(s)
+	if loc == nil {
+		return -1
+	}
+
+	depth := 0
+
+	var quote rune
+
+	for i := loc[1] - 1; i < len(s); i++ {
+		r := rune(s[i])
+
+		switch {
+		case r == '\\':
+			i++
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+
+	return -1
+}
+
+var rgxMatcherStart = regexp.MustCompile(`^\s*K_[A-Z][A-Z0-9_]*\(`)
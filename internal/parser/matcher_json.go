@@ -0,0 +1,160 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+// matchJSON implements K_JSON(document). The actual value must be the same
+// JSON document, whatever the order of the keys, the spacing or the way the
+// numbers are written.
+func matchJSON(actual string, args []string) error {
+	expected, value, err := decodeJSONPair(actual, args)
+	if err != nil {
+		return err
+	}
+
+	if !jsonContains(expected, value, false) {
+		return ErrJSONMismatch
+	}
+
+	return nil
+}
+
+// matchJSONContains implements K_JSON_CONTAINS(document). The actual value
+// must contain the document, like the @> operator of jsonb: an object contains
+// the keys of the expected object with values that contain the expected ones,
+// and an array contains every element of the expected array.
+func matchJSONContains(actual string, args []string) error {
+	expected, value, err := decodeJSONPair(actual, args)
+	if err != nil {
+		return err
+	}
+
+	if !jsonContains(expected, value, true) {
+		return ErrJSONNotContained
+	}
+
+	return nil
+}
+
+func decodeJSONPair(actual string, args []string) (any, any, error) {
+	if err := checkArgs(args, 1, 1); err != nil {
+		return nil, nil, err
+	}
+
+	expected, err := decodeJSON(args[0])
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrInvalidMatcher, err)
+	}
+
+	if actual == NullValue {
+		return nil, nil, ErrNull
+	}
+
+	value, err := decodeJSON(actual)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return expected, value, nil
+}
+
+func decodeJSON(s string) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader([]byte(s)))
+	decoder.UseNumber()
+
+	var doc any
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidJSON, err)
+	}
+
+	if decoder.More() {
+		return nil, fmt.Errorf("%w: unexpected data after the document", ErrInvalidJSON)
+	}
+
+	return doc, nil
+}
+
+// jsonContains reports whether actual is the expected document or, with
+// subset, contains it.
+func jsonContains(expected, actual any, subset bool) bool {
+	switch expected := expected.(type) {
+	case map[string]any:
+		actual, ok := actual.(map[string]any)
+		if !ok || (!subset && len(actual) != len(expected)) {
+			return false
+		}
+
+		for key, value := range expected {
+			actualValue, ok := actual[key]
+			if !ok || !jsonContains(value, actualValue, subset) {
+				return false
+			}
+		}
+
+		return true
+	case []any:
+		actual, ok := actual.([]any)
+		if !ok {
+			return false
+		}
+
+		if subset {
+			return jsonArrayContains(expected, actual)
+		}
+
+		if len(actual) != len(expected) {
+			return false
+		}
+
+		for i := range expected {
+			if !jsonContains(expected[i], actual[i], false) {
+				return false
+			}
+		}
+
+		return true
+	case json.Number:
+		actual, ok := actual.(json.Number)
+
+		return ok && jsonNumbersEqual(expected, actual)
+	default:
+		return expected == actual
+	}
+}
+
+// jsonArrayContains reports whether every element of expected is contained
+// in an element of actual, in any order.
+func jsonArrayContains(expected, actual []any) bool {
+	for _, value := range expected {
+		found := false
+
+		for _, actualValue := range actual {
+			if jsonContains(value, actualValue, true) {
+				found = true
+
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+func jsonNumbersEqual(a, b json.Number) bool {
+	x, okX := new(big.Rat).SetString(a.String())
+	y, okY := new(big.Rat).SetString(b.String())
+
+	if !okX || !okY {
+		return a == b
+	}
+
+	return x.Cmp(y) == 0
+}
@@ -0,0 +1,89 @@
+package parser
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// defaultRelativeTolerance is used by K_APPROX when no tolerance is given, so
+// that it only absorbs the differences of representation, e.g. 18 and 18.0.
+const defaultRelativeTolerance = 1e-9
+
+// matchApprox implements K_APPROX(value[, tolerance]). The actual value must
+// be within tolerance of value. A tolerance ending with % is relative to
+// value, e.g. K_APPROX(200, 1%) matches 198 to 202.
+func matchApprox(actual string, args []string) error {
+	if err := checkArgs(args, 1, 2); err != nil {
+		return err
+	}
+
+	expected, err := parseNumber(args[0])
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidMatcher, err)
+	}
+
+	tolerance := defaultRelativeTolerance * math.Abs(expected)
+
+	if len(args) == 2 {
+		if tolerance, err = parseTolerance(args[1], expected); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidMatcher, err)
+		}
+	}
+
+	value, err := parseNumber(actual)
+	if err != nil {
+		return err
+	}
+
+	if math.IsNaN(expected) || math.IsInf(expected, 0) {
+		if value == expected || (math.IsNaN(value) && math.IsNaN(expected)) {
+			return nil
+		}
+
+		return fmt.Errorf("%w: %s is not %s", ErrNotApprox, actual, args[0])
+	}
+
+	if diff := math.Abs(value - expected); !(diff <= tolerance) {
+		return fmt.Errorf("%w: %s differs from %s by %g, more than %g", ErrNotApprox, actual, args[0], diff, tolerance)
+	}
+
+	return nil
+}
+
+// parseNumber parses a value rendered by Postgres as a number, including
+// NaN and Infinity.
+func parseNumber(s string) (float64, error) {
+	if s == NullValue {
+		return 0, ErrNull
+	}
+
+	value, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrNotANumber, s)
+	}
+
+	return value, nil
+}
+
+// parseTolerance parses an absolute tolerance, or a relative one to expected
+// when it ends with %.
+func parseTolerance(s string, expected float64) (float64, error) {
+	percent, relative := strings.CutSuffix(strings.TrimSpace(s), "%")
+
+	tolerance, err := parseNumber(percent)
+	if err != nil {
+		return 0, fmt.Errorf("invalid tolerance: %w", err)
+	}
+
+	if tolerance < 0 {
+		return 0, fmt.Errorf("invalid tolerance: %s is negative", s)
+	}
+
+	if relative {
+		return tolerance / 100 * math.Abs(expected), nil //nolint:mnd // a percentage
+	}
+
+	return tolerance, nil
+}
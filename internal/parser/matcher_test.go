@@ -0,0 +1,399 @@
+package parser
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractRowMatchers(t *testing.T) {
+	t.Parallel()
+
+	row, err := extractRow(`1,K_APPROX(3.14, 0.001),"a,b",K_ANY, K_APPROX(18)`)
+	require.NoError(t, err)
+	require.Equal(t, []string{"1", "K_APPROX(3.14, 0.001)", "a,b", "K_ANY", " K_APPROX(18)"}, row)
+}
+
+func TestParseMatcher(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		cell string
+		name string
+		args []string
+		ok   bool
+	}{
+		"plain":           {cell: "3.14"},
+		"unknown keyword": {cell: "K_SOMETHING"},
+		"keyword":         {cell: "K_ANY", name: "ANY", ok: true},
+		"no arguments":    {cell: "K_ANY()", name: "ANY", ok: true},
+		"arguments":       {cell: " K_APPROX(3.14, 1%) ", name: "APPROX", args: []string{"3.14", "1%"}, ok: true},
+		"nested":          {cell: `K_X({"a": 1, "b": [1, 2]}, 'it''s, here')`, name: "X", args: []string{`{"a": 1, "b": [1, 2]}`, "it's, here"}, ok: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			matcherName, args, ok := parseMatcher(tc.cell)
+			require.Equal(t, tc.ok, ok)
+
+			if ok {
+				require.Equal(t, tc.name, matcherName)
+				require.Equal(t, tc.args, args)
+			}
+		})
+	}
+}
+
+func TestMatchCell(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	pg := func(d time.Duration) string {
+		return now.Add(d).UTC().Format(psqlTimestamptzFormat)
+	}
+
+	paris, err := time.LoadLocation("Europe/Paris")
+	require.NoError(t, err)
+
+	type testCase struct {
+		expected string
+		actual   string
+		err      error
+	}
+
+	// The test cases of every matcher, by name.
+	testCases := map[string]map[string]testCase{
+		"ANY": {
+			"value":     {expected: "K_ANY", actual: "1"},
+			"null":      {expected: "K_ANY", actual: NullValue},
+			"arguments": {expected: "K_ANY(1)", actual: "1", err: ErrInvalidMatcher},
+		},
+		"ANY_NOT_NULL": {
+			"value": {expected: "K_ANY_NOT_NULL", actual: ""},
+			"null":  {expected: "K_ANY_NOT_NULL", actual: NullValue, err: ErrAnyNotNullButEmpty},
+		},
+		"NULL": {
+			"null":  {expected: "K_NULL", actual: NullValue},
+			"empty": {expected: "K_NULL", actual: "", err: ErrNotNull},
+			"text":  {expected: "K_NULL", actual: "K_NULL", err: ErrNotNull},
+		},
+		"APPROX": {
+			"normalized":         {expected: "K_APPROX(18)", actual: "18.00"},
+			"not normalized":     {expected: "K_APPROX(18)", actual: "18.01", err: ErrNotApprox},
+			"absolute":           {expected: "K_APPROX(3.14, 0.001)", actual: "3.1409"},
+			"absolute too far":   {expected: "K_APPROX(3.14, 0.001)", actual: "3.142", err: ErrNotApprox},
+			"relative":           {expected: "K_APPROX(200, 1%)", actual: "198"},
+			"relative too far":   {expected: "K_APPROX(-200, 1%)", actual: "-197.9", err: ErrNotApprox},
+			"exponent":           {expected: "K_APPROX(1e-3, 1e-6)", actual: "0.001"},
+			"nan":                {expected: "K_APPROX(NaN)", actual: "NaN"},
+			"not a number":       {expected: "K_APPROX(1)", actual: "one", err: ErrNotANumber},
+			"null":               {expected: "K_APPROX(1)", actual: NullValue, err: ErrNull},
+			"invalid tolerance":  {expected: "K_APPROX(1, -1)", actual: "1", err: ErrInvalidMatcher},
+			"too many arguments": {expected: "K_APPROX(1, 2, 3)", actual: "1", err: ErrInvalidMatcher},
+		},
+		"REGEX": {
+			"match":       {expected: `K_REGEX(ORD-\d{4}-\d{6})`, actual: "ORD-2024-000123"},
+			"whole value": {expected: `K_REGEX(ORD-\d{4})`, actual: "ORD-2024-000123", err: ErrNoPatternMatch},
+			"alternation": {expected: `K_REGEX(a|b)`, actual: "b"},
+			"quoted":      {expected: `K_REGEX('\d{1,3}, \d+')`, actual: "12, 345"},
+			"null":        {expected: `K_REGEX(.*)`, actual: NullValue, err: ErrNull},
+			"invalid":     {expected: `K_REGEX(a**)`, actual: "1", err: ErrInvalidMatcher},
+		},
+		"LIKE": {
+			"match":          {expected: `K_LIKE(ORD-%)`, actual: "ORD-2024-000123"},
+			"single":         {expected: `K_LIKE(ORD-____-%)`, actual: "ORD-24-000123", err: ErrNoPatternMatch},
+			"escaped":        {expected: `K_LIKE(100\%)`, actual: "100%"},
+			"escaped miss":   {expected: `K_LIKE(100\%)`, actual: "1000", err: ErrNoPatternMatch},
+			"meta":           {expected: `K_LIKE(a.c%)`, actual: "abc", err: ErrNoPatternMatch},
+			"case sensitive": {expected: `K_LIKE(ord-%)`, actual: "ORD-1", err: ErrNoPatternMatch},
+		},
+		"ILIKE": {
+			"match": {expected: `K_ILIKE(ord-%)`, actual: "ORD-1"},
+		},
+		"JSON": {
+			"same":             {expected: `K_JSON({"a": "b"})`, actual: `{"a": "b"}`},
+			"keys order":       {expected: `K_JSON({"a": 1, "b": [1, 2]})`, actual: `{"b":[1,2],"a":1}`},
+			"numbers":          {expected: `K_JSON({"a": 18})`, actual: `{"a": 18.0}`},
+			"array order":      {expected: `K_JSON([1, 2])`, actual: `[2, 1]`, err: ErrJSONMismatch},
+			"extra key":        {expected: `K_JSON({"a": 1})`, actual: `{"a": 1, "b": 2}`, err: ErrJSONMismatch},
+			"json null":        {expected: `K_JSON(null)`, actual: `null`},
+			"sql null":         {expected: `K_JSON(null)`, actual: NullValue, err: ErrNull},
+			"invalid actual":   {expected: `K_JSON({})`, actual: `{'a': 'b'}`, err: ErrInvalidJSON},
+			"invalid expected": {expected: `K_JSON({)`, actual: `{}`, err: ErrInvalidMatcher},
+		},
+		"JSON_CONTAINS": {
+			"match":                 {expected: `K_JSON_CONTAINS({"a": {"b": 1}, "c": [3]})`, actual: `{"a": {"b": 1, "x": 2}, "c": [1, 2, 3], "d": true}`},
+			"nested":                {expected: `K_JSON_CONTAINS([{"id": 2}])`, actual: `[{"id": 1, "n": "a"}, {"id": 2, "n": "b"}]`},
+			"not contained":         {expected: `K_JSON_CONTAINS({"a": {"b": 2}})`, actual: `{"a": {"b": 1}}`, err: ErrJSONNotContained},
+			"not contained element": {expected: `K_JSON_CONTAINS([4])`, actual: `[1, 2, 3]`, err: ErrJSONNotContained},
+		},
+		"NOW": {
+			"match":             {expected: "K_NOW", actual: pg(-10 * time.Second)},
+			"window":            {expected: "K_NOW(±5s)", actual: pg(-2 * time.Second)},
+			"window ascii":      {expected: "K_NOW(+-5s)", actual: pg(2 * time.Second)},
+			"too old":           {expected: "K_NOW(±5s)", actual: pg(-time.Minute), err: ErrOutsideWindow},
+			"offset":            {expected: "K_NOW(5s)", actual: now.In(time.FixedZone("", 19800)).Format("2006-01-02 15:04:05.999999-07:00")},
+			"without time zone": {expected: "K_NOW(5s)", actual: now.UTC().Format("2006-01-02 15:04:05.999999")},
+			"in time zone":      {expected: "K_NOW(5s, Europe/Paris)", actual: now.In(paris).Format("2006-01-02 15:04:05.999999")},
+			"null":              {expected: "K_NOW", actual: NullValue, err: ErrNull},
+			"not a timestamp":   {expected: "K_NOW", actual: "yesterday", err: ErrNotATimestamp},
+			"invalid window":    {expected: "K_NOW(soon)", actual: pg(0), err: ErrInvalidMatcher},
+		},
+		"TIMESTAMP_AFTER": {
+			"match":     {expected: "K_TIMESTAMP_AFTER(2024-01-01 00:00:00+00)", actual: pg(0)},
+			"relative":  {expected: "K_TIMESTAMP_AFTER(now-1h)", actual: pg(-time.Minute)},
+			"not after": {expected: "K_TIMESTAMP_AFTER(now-1h)", actual: pg(-2 * time.Hour), err: ErrOutsideWindow},
+		},
+		"TIMESTAMP_BEFORE": {
+			"match":      {expected: "K_TIMESTAMP_BEFORE(now+1m)", actual: pg(0)},
+			"not before": {expected: "K_TIMESTAMP_BEFORE(2024-01-01)", actual: "2024-01-01 00:00:01", err: ErrOutsideWindow},
+		},
+		"DATE_TODAY": {
+			"match":        {expected: "K_DATE_TODAY", actual: now.UTC().Format(time.DateOnly)},
+			"timestamp":    {expected: "K_DATE_TODAY", actual: pg(0)},
+			"not today":    {expected: "K_DATE_TODAY", actual: now.UTC().AddDate(0, 0, -1).Format(time.DateOnly), err: ErrOutsideWindow},
+			"in time zone": {expected: "K_DATE_TODAY(Europe/Paris)", actual: now.In(paris).Format(time.DateOnly)},
+		},
+		"UUID": {
+			"match":      {expected: "K_UUID", actual: "01020304-0506-0708-090a-0b0c0d0e0f10"},
+			"not a uuid": {expected: "K_UUID", actual: "01020304-0506-0708-090a", err: ErrNotAUUID},
+			"null":       {expected: "K_UUID", actual: NullValue, err: ErrNull},
+		},
+		"INT": {
+			"match":         {expected: "K_INT", actual: "-42"},
+			"big int":       {expected: "K_INT(>0)", actual: "123456789012345678901234567890"},
+			"not an int":    {expected: "K_INT", actual: "4.2", err: ErrNotAnInt},
+			"range":         {expected: "K_INT(>=1, <10)", actual: "9"},
+			"out of range":  {expected: "K_INT(>0)", actual: "0", err: ErrOutOfRange},
+			"not equal":     {expected: "K_INT(!=0)", actual: "0", err: ErrOutOfRange},
+			"bad condition": {expected: "K_INT(~1)", actual: "1", err: ErrInvalidMatcher},
+		},
+		"NUMERIC": {
+			"match":       {expected: "K_NUMERIC", actual: "18.00"},
+			"range":       {expected: "K_NUMERIC(> 0.5, <= 1)", actual: "1.00"},
+			"too big":     {expected: "K_NUMERIC(<1)", actual: "1.01", err: ErrOutOfRange},
+			"not numeric": {expected: "K_NUMERIC", actual: "1e3", err: ErrNotANumber},
+		},
+		"BOOL": {
+			"match":      {expected: "K_BOOL", actual: "t"},
+			"word":       {expected: "K_BOOL", actual: "false"},
+			"not a bool": {expected: "K_BOOL", actual: "yes", err: ErrNotABool},
+			"arguments":  {expected: "K_BOOL(t)", actual: "t", err: ErrInvalidMatcher},
+		},
+	}
+
+	for matcher, matcherCases := range testCases {
+		t.Run(matcher, func(t *testing.T) {
+			t.Parallel()
+
+			for name, tc := range matcherCases {
+				t.Run(name, func(t *testing.T) {
+					t.Parallel()
+
+					err := matchCell(tc.expected, tc.actual)
+					if tc.err == nil {
+						require.NoError(t, err)
+
+						return
+					}
+
+					require.ErrorIs(t, err, tc.err)
+				})
+			}
+		})
+	}
+}
+
+func TestCheckPairMatchers(t *testing.T) {
+	t.Parallel()
+
+	expected := [][]string{{"a", "K_APPROX(0.3, 0.01)"}, {"b", "K_APPROX(10)"}}
+
+	err := checkPair(pair{
+		expected: expected,
+		actual:   [][]string{{"b", "10.0"}, {"a", "0.30000000000000004"}},
+	})
+	require.NoError(t, err)
+
+	err = checkPair(pair{
+		expected: expected,
+		actual:   [][]string{{"b", "10.5"}, {"a", "0.3"}},
+	})
+	require.ErrorIs(t, err, ErrRowsMismatch)
+	require.ErrorIs(t, err, ErrNotApprox)
+	require.ErrorContains(t, err, "column 2: K_APPROX(10) does not match \"10.5\"")
+	require.ErrorContains(t, err, "! b | *K_APPROX(10)*      | b | *10.5*")
+}
+
+func TestCheckPairNull(t *testing.T) {
+	t.Parallel()
+
+	expected := [][]string{{"1", "K_NULL"}, {"2", ""}}
+
+	require.NoError(t, checkPair(pair{expected: expected, actual: [][]string{{"1", NullValue}, {"2", ""}}}))
+
+	// A text value cannot be mistaken for a NULL, nor a NULL for an empty string.
+	err := checkPair(pair{expected: expected, actual: [][]string{{"1", "K_NULL"}, {"2", ""}}})
+	require.ErrorIs(t, err, ErrNotNull)
+	require.ErrorContains(t, err, `K_NULL does not match "K_NULL"`)
+
+	err = checkPair(pair{expected: expected, actual: [][]string{{"1", NullValue}, {"2", NullValue}}})
+	require.ErrorIs(t, err, ErrRowsMismatch)
+	require.ErrorContains(t, err, "! 2 | **     | 2 | *∅*")
+}
+
+func TestCheckPairOverlappingMatchers(t *testing.T) {
+	t.Parallel()
+
+	// K_ANY matches both rows, so it must leave 1 to K_INT.
+	expected := [][]string{{"K_INT"}, {"K_ANY"}}
+
+	require.NoError(t, checkPair(pair{expected: expected, actual: [][]string{{"x"}, {"1"}}}))
+	require.NoError(t, checkPair(pair{expected: expected, actual: [][]string{{"1"}, {"x"}}}))
+
+	err := checkPair(pair{expected: expected, actual: [][]string{{"x"}, {"y"}}})
+	require.ErrorIs(t, err, ErrRowsMismatch)
+	require.ErrorContains(t, err, "! *K_INT*  | *y*")
+}
+
+func TestCheckPairMatcherCalls(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+
+	require.NoError(t, RegisterMatcher("TEST_COUNTED", func(_ string, _ []string) error {
+		calls++
+
+		return nil
+	}))
+
+	t.Cleanup(func() { UnregisterMatcher("TEST_COUNTED") })
+
+	const n = 1000
+
+	expected := make([][]string, n)
+	actual := make([][]string, n)
+
+	for i := range n {
+		expected[i] = []string{strconv.Itoa(i), "K_TEST_COUNTED"}
+		actual[i] = []string{strconv.Itoa(n - 1 - i), "x"}
+	}
+
+	// Every row is checked against the actual row it is aligned with only,
+	// rather than against every actual row.
+	require.NoError(t, checkPair(pair{expected: expected, actual: actual}))
+	require.Equal(t, n, calls)
+}
+
+func TestUnknownMatcher(t *testing.T) {
+	t.Parallel()
+
+	_, err := getInstructions([]parsedLine{
+		parseLine("-- START_TEST"),
+		parseLine("-- ROW 1,K_APROX(1)"),
+		parseLine("-- END_TEST"),
+	})
+	require.ErrorIs(t, err, ErrUnknownMatcher)
+}
+
+func TestExtractRowJSON(t *testing.T) {
+	t.Parallel()
+
+	row, err := extractRow(`1,K_JSON({"a": ")", "b": [1, 2]}),x`)
+	require.NoError(t, err)
+	require.Equal(t, []string{"1", `K_JSON({"a": ")", "b": [1, 2]})`, "x"}, row)
+}
+
+func TestCountMatcher(t *testing.T) {
+	t.Parallel()
+
+	instr, err := getInstructions([]parsedLine{
+		parseLine("-- START_TEST"),
+		parseLine("-- COUNT K_INT(>0)"),
+		parseLine("-- END_TEST"),
+	})
+	require.NoError(t, err)
+	require.NoError(t, checkPair(pair{expected: instr.values, actual: [][]string{{"12"}}}))
+	require.ErrorIs(t, checkPair(pair{expected: instr.values, actual: [][]string{{"0"}}}), ErrOutOfRange)
+}
+
+func TestExtractCountMatchers(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		content  string
+		expected [][]string
+	}{
+		"approx": {
+			content:  "K_APPROX(3.14, 0.001)",
+			expected: [][]string{{"K_APPROX(3.14, 0.001)"}},
+		},
+		"range": {
+			content:  "K_INT(>=1, <10) 4",
+			expected: [][]string{{"K_INT(>=1, <10)"}, {"4"}},
+		},
+		"now": {
+			content:  "K_NOW(5s, Europe/Paris)  K_ANY",
+			expected: [][]string{{"K_NOW(5s, Europe/Paris)"}, {"K_ANY"}},
+		},
+		"quoted argument": {
+			content:  "K_REGEX('a b (c)') 2",
+			expected: [][]string{{"K_REGEX('a b (c)')"}, {"2"}},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := extractCount(tt.content)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestCountMatcherArgs(t *testing.T) {
+	t.Parallel()
+
+	instr, err := getInstructions([]parsedLine{
+		parseLine("-- START_TEST"),
+		parseLine("-- COUNT K_INT(>=1, <10) K_APPROX(3.14, 0.001)"),
+		parseLine("-- END_TEST"),
+	})
+	require.NoError(t, err)
+	require.NoError(t, checkPair(pair{expected: instr.values, actual: [][]string{{"9"}, {"3.1405"}}}))
+	require.ErrorIs(t, checkPair(pair{expected: instr.values, actual: [][]string{{"10"}, {"3.14"}}}), ErrOutOfRange)
+}
+
+func TestRegisterMatcher(t *testing.T) {
+	t.Parallel()
+
+	require.ErrorIs(t, RegisterMatcher("even", nil), ErrInvalidMatcherName)
+	require.ErrorIs(t, RegisterMatcher("K_APPROX", nil), ErrMatcherExists)
+
+	err := RegisterMatcher("TEST_EVEN", func(actual string, _ []string) error {
+		if !strings.HasSuffix(actual, "0") && !strings.HasSuffix(actual, "2") {
+			return ErrOutOfRange
+		}
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	t.Cleanup(func() { UnregisterMatcher("TEST_EVEN") })
+
+	instr, err := getInstructions([]parsedLine{
+		parseLine("-- START_TEST"),
+		parseLine("-- COUNT K_TEST_EVEN"),
+		parseLine("-- END_TEST"),
+	})
+	require.NoError(t, err)
+	require.NoError(t, checkPair(pair{expected: instr.values, actual: [][]string{{"12"}}}))
+	require.ErrorIs(t, checkPair(pair{expected: instr.values, actual: [][]string{{"13"}}}), ErrOutOfRange)
+}
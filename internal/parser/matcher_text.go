@@ -0,0 +1,108 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// matchRegex implements K_REGEX(pattern). The whole actual value must match
+// the regular expression, in the RE2 syntax.
+func matchRegex(actual string, args []string) error {
+	return matchCompiled(compileRegex, actual, args)
+}
+
+func compileRegex(args []string) (func(actual string) error, error) {
+	if err := checkArgs(args, 1, 1); err != nil {
+		return nil, err
+	}
+
+	rgx, err := regexp.Compile(`^(?:` + args[0] + `)$`)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidMatcher, err)
+	}
+
+	return func(actual string) error {
+		return matchText(rgx, actual)
+	}, nil
+}
+
+// matchLike implements K_LIKE(pattern), where % matches any sequence of
+// characters, _ matches any single character and \ escapes them, like the
+// LIKE operator of SQL.
+func matchLike(actual string, args []string) error {
+	return matchCompiled(compileLike, actual, args)
+}
+
+func compileLike(args []string) (func(actual string) error, error) {
+	return compileLikePattern(args, "")
+}
+
+// matchILike implements K_ILIKE(pattern), the case insensitive K_LIKE.
+func matchILike(actual string, args []string) error {
+	return matchCompiled(compileILike, actual, args)
+}
+
+func compileILike(args []string) (func(actual string) error, error) {
+	return compileLikePattern(args, "(?i)")
+}
+
+func compileLikePattern(args []string, flags string) (func(actual string) error, error) {
+	if err := checkArgs(args, 1, 1); err != nil {
+		return nil, err
+	}
+
+	rgx, err := regexp.Compile(flags + likeToRegex(args[0]))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidMatcher, err)
+	}
+
+	return func(actual string) error {
+		return matchText(rgx, actual)
+	}, nil
+}
+
+func matchText(rgx *regexp.Regexp, actual string) error {
+	if actual == NullValue {
+		return ErrNull
+	}
+
+	if !rgx.MatchString(actual) {
+		return ErrNoPatternMatch
+	}
+
+	return nil
+}
+
+// likeToRegex returns the anchored regular expression of a LIKE pattern.
+func likeToRegex(pattern string) string {
+	rgx := strings.Builder{}
+	rgx.WriteString("^(?s:")
+
+	escaped := false
+
+	for _, r := range pattern {
+		switch {
+		case escaped:
+			rgx.WriteString(regexp.QuoteMeta(string(r)))
+
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '%':
+			rgx.WriteString(".*")
+		case r == '_':
+			rgx.WriteString(".")
+		default:
+			rgx.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+
+	if escaped {
+		rgx.WriteString(regexp.QuoteMeta(`\`))
+	}
+
+	rgx.WriteString(")$")
+
+	return rgx.String()
+}
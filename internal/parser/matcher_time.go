@@ -0,0 +1,203 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// defaultNowWindow is the window of K_NOW when none is given.
+const defaultNowWindow = time.Minute
+
+// timestampLayouts are the layouts a timestamp rendered by Postgres, or written
+// in an expected cell, is parsed with. A timestamp without a time zone is UTC.
+var timestampLayouts = []string{
+	"2006-01-02 15:04:05.999999999-07",
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999-07:00:00",
+	"2006-01-02 15:04:05.999999999",
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	time.DateOnly,
+}
+
+// rgxRelativeTime matches a time relative to now, such as now-1h.
+var rgxRelativeTime = regexp.MustCompile(`^now(?:\s*([+-])\s*(\S+))?$`)
+
+// matchNow implements K_NOW([±window[, time zone]]). The actual timestamp must
+// be within window of the time of the comparison, a minute by default. The
+// window is a duration such as 5s, optionally prefixed with ± or +-. The
+// time zone is the one of the timestamps without one, UTC by default.
+func matchNow(actual string, args []string) error {
+	if err := checkArgs(args, 0, 2); err != nil { //nolint:mnd // the window and the time zone
+		return err
+	}
+
+	window := defaultNowWindow
+
+	if len(args) > 0 && args[0] != "" {
+		var err error
+
+		if window, err = parseWindow(args[0]); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidMatcher, err)
+		}
+	}
+
+	loc, err := matcherLocation(args, 1)
+	if err != nil {
+		return err
+	}
+
+	value, err := parseTimestamp(actual, loc)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	if diff := value.Sub(now).Abs(); diff > window {
+		return fmt.Errorf("%w: %s is %s away from now, more than %s", ErrOutsideWindow, actual, diff.Round(time.Millisecond), window)
+	}
+
+	return nil
+}
+
+// matchTimestampAfter implements K_TIMESTAMP_AFTER(time). The actual
+// timestamp must be after time, which is a timestamp, now, or a time relative
+// to now such as now-1h.
+func matchTimestampAfter(actual string, args []string) error {
+	return matchTimestampBound(actual, args, func(value, bound time.Time) bool { return value.After(bound) }, "after")
+}
+
+// matchTimestampBefore implements K_TIMESTAMP_BEFORE(time), the opposite of
+// K_TIMESTAMP_AFTER.
+func matchTimestampBefore(actual string, args []string) error {
+	return matchTimestampBound(actual, args, func(value, bound time.Time) bool { return value.Before(bound) }, "before")
+}
+
+func matchTimestampBound(actual string, args []string, inBound func(value, bound time.Time) bool, what string) error {
+	if err := checkArgs(args, 1, 1); err != nil {
+		return err
+	}
+
+	bound, err := parseTimeArg(args[0])
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidMatcher, err)
+	}
+
+	value, err := parseTimestamp(actual, time.UTC)
+	if err != nil {
+		return err
+	}
+
+	if !inBound(value, bound) {
+		return fmt.Errorf("%w: %s is not %s %s", ErrOutsideWindow, actual, what, bound.Format(time.RFC3339Nano))
+	}
+
+	return nil
+}
+
+// matchDateToday implements K_DATE_TODAY([time zone]). The actual value must
+// be the date of today in the time zone, UTC by default. A timestamp is
+// converted to the time zone first.
+func matchDateToday(actual string, args []string) error {
+	if err := checkArgs(args, 0, 1); err != nil {
+		return err
+	}
+
+	loc, err := matcherLocation(args, 0)
+	if err != nil {
+		return err
+	}
+
+	value, err := parseTimestamp(actual, loc)
+	if err != nil {
+		return err
+	}
+
+	today := time.Now().In(loc).Format(time.DateOnly)
+	if date := value.In(loc).Format(time.DateOnly); date != today {
+		return fmt.Errorf("%w: %s is not today, %s", ErrOutsideWindow, date, today)
+	}
+
+	return nil
+}
+
+// parseTimestamp parses a date or a timestamp rendered by Postgres. The
+// timestamps without a time zone are in loc.
+func parseTimestamp(s string, loc *time.Location) (time.Time, error) {
+	if s == NullValue {
+		return time.Time{}, ErrNull
+	}
+
+	s = strings.TrimSpace(s)
+
+	for _, layout := range timestampLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("%w: %q", ErrNotATimestamp, s)
+}
+
+// parseTimeArg parses the time argument of a matcher: a timestamp, now or a
+// time relative to now.
+func parseTimeArg(s string) (time.Time, error) {
+	matches := rgxRelativeTime.FindStringSubmatch(strings.ToLower(strings.TrimSpace(s)))
+	if matches == nil {
+		return parseTimestamp(s, time.UTC)
+	}
+
+	now := time.Now()
+
+	if matches[1] == "" {
+		return now, nil
+	}
+
+	offset, err := time.ParseDuration(matches[2])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid offset: %w", err)
+	}
+
+	if matches[1] == "-" {
+		offset = -offset
+	}
+
+	return now.Add(offset), nil
+}
+
+// parseWindow parses a duration optionally prefixed with ± or +-.
+func parseWindow(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+
+	for _, prefix := range []string{"±", "+-", "+/-"} {
+		s = strings.TrimPrefix(s, prefix)
+	}
+
+	window, err := time.ParseDuration(strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("invalid window: %w", err)
+	}
+
+	if window < 0 {
+		return 0, fmt.Errorf("invalid window: %s is negative", s)
+	}
+
+	return window, nil
+}
+
+// matcherLocation returns the time zone given as the i-th argument, or UTC.
+func matcherLocation(args []string, i int) (*time.Location, error) {
+	if len(args) <= i || args[i] == "" {
+		return time.UTC, nil
+	}
+
+	loc, err := time.LoadLocation(args[i])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidMatcher, err)
+	}
+
+	return loc, nil
+}
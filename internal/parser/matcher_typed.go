@@ -0,0 +1,118 @@
+package parser
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+var (
+	rgxUUID    = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	rgxInt     = regexp.MustCompile(`^[+-]?[0-9]+$`)
+	rgxNumeric = regexp.MustCompile(`^[+-]?([0-9]+(\.[0-9]*)?|\.[0-9]+)$`)
+	// rgxRangeCondition matches a condition of matchRange, such as >=10.
+	rgxRangeCondition = regexp.MustCompile(`^(>=|<=|!=|<>|>|<|=)\s*(.+)$`)
+)
+
+// matchUUID implements K_UUID, a UUID in its canonical 8-4-4-4-12 form.
+func matchUUID(actual string, args []string) error {
+	return matchShape(actual, args, rgxUUID, ErrNotAUUID)
+}
+
+// matchBool implements K_BOOL, t or f as rendered by psql, or true or false.
+func matchBool(actual string, args []string) error {
+	if err := checkArgs(args, 0, 0); err != nil {
+		return err
+	}
+
+	switch actual {
+	case "t", "f", "true", "false":
+		return nil
+	case NullValue:
+		return ErrNull
+	default:
+		return fmt.Errorf("%w: %q", ErrNotABool, actual)
+	}
+}
+
+// matchInt implements K_INT[(condition...)], see matchRange.
+func matchInt(actual string, args []string) error {
+	if err := matchShape(actual, nil, rgxInt, ErrNotAnInt); err != nil {
+		return err
+	}
+
+	return matchRange(actual, args)
+}
+
+// matchNumeric implements K_NUMERIC[(condition...)], see matchRange.
+func matchNumeric(actual string, args []string) error {
+	if err := matchShape(actual, nil, rgxNumeric, ErrNotANumber); err != nil {
+		return err
+	}
+
+	return matchRange(actual, args)
+}
+
+func matchShape(actual string, args []string, rgx *regexp.Regexp, notMatching error) error {
+	if err := checkArgs(args, 0, 0); err != nil {
+		return err
+	}
+
+	if actual == NullValue {
+		return ErrNull
+	}
+
+	if !rgx.MatchString(actual) {
+		return fmt.Errorf("%w: %q", notMatching, actual)
+	}
+
+	return nil
+}
+
+// matchRange checks that the number actual meets every condition of args. A
+// condition is a comparison operator (>, >=, <, <=, = or !=) followed by a
+// number, e.g. >0.
+func matchRange(actual string, args []string) error {
+	value, ok := new(big.Rat).SetString(actual)
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrNotANumber, actual)
+	}
+
+	for _, arg := range args {
+		matches := rgxRangeCondition.FindStringSubmatch(strings.TrimSpace(arg))
+		if matches == nil {
+			return fmt.Errorf("%w: invalid condition %q", ErrInvalidMatcher, arg)
+		}
+
+		bound, ok := new(big.Rat).SetString(strings.TrimSpace(matches[2]))
+		if !ok {
+			return fmt.Errorf("%w: invalid number in condition %q", ErrInvalidMatcher, arg)
+		}
+
+		cmp := value.Cmp(bound)
+
+		var met bool
+
+		switch matches[1] {
+		case ">":
+			met = cmp > 0
+		case ">=":
+			met = cmp >= 0
+		case "<":
+			met = cmp < 0
+		case "<=":
+			met = cmp <= 0
+		case "=":
+			met = cmp == 0
+		default:
+			met = cmp != 0
+		}
+
+		if !met {
+			return fmt.Errorf("%w: %s is not %s", ErrOutOfRange, actual, arg)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+package parser
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Options configures which tests are run and how.
+type Options struct {
+	// Run, when set, only selects the tests whose display name matches.
+	Run *regexp.Regexp
+	// Tags only selects the tests that have at least one of the tags, and
+	// none of the tags prefixed with "!". A test is not filtered out by tags
+	// when Tags only contains negated tags.
+	Tags []string
+	// Isolation tells whether the changes made by a test are rolled back.
+	Isolation Isolation
+	// Parallel is the maximum number of tests of a file that run at the
+	// same time. With 0 or 1, they run one after the other, in order. The
+	// tests must not depend on each other, which IsolationTransaction helps
+	// with.
+	Parallel int
+	// FailFast stops a file at its first failure: a file with a mistake is
+	// not run at all, and no test is started once one has failed. Otherwise,
+	// the well formed tests of a file run even when others are broken.
+	FailFast bool
+}
+
+// Validate returns an error if the options cannot be used together.
+func (o *Options) Validate() error {
+	if o.Parallel > 1 && o.Isolation == IsolationSavepoint {
+		return ErrParallelSavepoint
+	}
+
+	return nil
+}
+
+// ParseTags splits a comma separated list of tags, such as "smoke,!slow".
+func ParseTags(s string) []string {
+	var tags []string
+
+	for _, tag := range strings.Split(s, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "@")
+		if tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
+}
+
+// Selects reports whether test must be run.
+func (o *Options) Selects(test *Test) bool {
+	if o.Run != nil && !o.Run.MatchString(test.DisplayName()) {
+		return false
+	}
+
+	wanted := false
+	foundWanted := false
+
+	for _, tag := range o.Tags {
+		if excluded, ok := strings.CutPrefix(tag, "!"); ok {
+			if slices.Contains(test.Tags, excluded) {
+				return false
+			}
+
+			continue
+		}
+
+		wanted = true
+
+		if slices.Contains(test.Tags, tag) {
+			foundWanted = true
+		}
+	}
+
+	return !wanted || foundWanted
+}
@@ -0,0 +1,45 @@
+package parser
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOptionsSelects(t *testing.T) {
+	t.Parallel()
+
+	smoke := &Test{Index: 1, Name: "orders_total", Tags: []string{"smoke"}}
+	slow := &Test{Index: 2, Name: "orders_by_customer", Tags: []string{"slow", "reporting"}}
+	unnamed := &Test{Index: 3}
+
+	tcs := map[string]struct {
+		opts     Options
+		selected []*Test
+	}{
+		"no filter":    {opts: Options{}, selected: []*Test{smoke, slow, unnamed}},
+		"run":          {opts: Options{Run: regexp.MustCompile(`^orders_`)}, selected: []*Test{smoke, slow}},
+		"run unnamed":  {opts: Options{Run: regexp.MustCompile(`test_3`)}, selected: []*Test{unnamed}},
+		"tag":          {opts: Options{Tags: ParseTags("smoke,reporting")}, selected: []*Test{smoke, slow}},
+		"negated tag":  {opts: Options{Tags: ParseTags("!slow")}, selected: []*Test{smoke, unnamed}},
+		"tag and !tag": {opts: Options{Tags: ParseTags("reporting, !slow")}, selected: nil},
+		"run and tags": {opts: Options{Run: regexp.MustCompile(`total`), Tags: ParseTags("@smoke")}, selected: []*Test{smoke}},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var selected []*Test
+
+			for _, test := range []*Test{smoke, slow, unnamed} {
+				if tc.opts.Selects(test) {
+					selected = append(selected, test)
+				}
+			}
+
+			require.Equal(t, tc.selected, selected)
+		})
+	}
+}
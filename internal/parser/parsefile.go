@@ -20,6 +20,8 @@ const (
 type parsedLine struct {
 	lineType lineType
 	line     string
+	// number is the 1-based line number in the file.
+	number int
 }
 
 var (
@@ -52,19 +54,27 @@ func parseLine(line string) parsedLine {
 	return pl
 }
 
+// parseFile reads the lines of filename. An error reading them is returned as
+// a *LineError at the line that could not be read.
 func parseFile(filename string) ([]parsedLine, error) {
 	rdr, err := os.Open(filepath.Clean(filename))
 	if err != nil {
 		return nil, fmt.Errorf("unable to open file: %w", err)
 	}
+	defer rdr.Close() //nolint:errcheck // the file is only read
 
 	bufioScanner := bufio.NewScanner(rdr)
 
 	res := []parsedLine{}
 
 	for bufioScanner.Scan() {
-		line := bufioScanner.Text()
-		res = append(res, parseLine(line))
+		pl := parseLine(bufioScanner.Text())
+		pl.number = len(res) + 1
+		res = append(res, pl)
+	}
+
+	if err := bufioScanner.Err(); err != nil {
+		return nil, &LineError{File: filename, Line: len(res) + 1, Err: fmt.Errorf("unable to read file: %w", err)}
 	}
 
 	return res, nil
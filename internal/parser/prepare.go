@@ -1,16 +1,103 @@
 package parser
 
-func prepairPair(p pair) (pair, error) {
-	sortRows(p.actual)
-	sortRows(p.expected)
+import (
+	"fmt"
+	"slices"
+)
 
-	actual, expected, err := replaceKeywords(p.actual, p.expected)
-	if err != nil {
-		return pair{}, err
+// checkPair returns a *MismatchError if the actual rows do not match the
+// expected ones. Unless p is ordered, every expected row may match any actual
+// row, see Diff.
+func checkPair(p pair) error {
+	mismatch := &MismatchError{
+		Expected: cloneRows(p.expected),
+		Actual:   cloneRows(p.actual),
+		Ordered:  p.ordered,
 	}
 
-	return pair{
-		actual:   actual,
-		expected: expected,
-	}, nil
+	if len(p.expected) != len(p.actual) {
+		mismatch.Err = ErrDifferentRowCount
+
+		return mismatch
+	}
+
+	if rowsEqual(p) {
+		return nil
+	}
+
+	lines := alignUnordered(p.expected, p.actual)
+	if p.ordered {
+		lines = alignOrdered(p.expected, p.actual)
+	}
+
+	for i, line := range lines {
+		if line.status == diffStatusEqual {
+			continue
+		}
+
+		mismatch.Err = lineMismatch(line)
+
+		switch {
+		case mismatch.Err != nil:
+		case p.ordered && matchesAnyRow(p.expected, line.actual):
+			mismatch.Err = fmt.Errorf("%w: row %d is out of place", ErrRowOutOfPlace, i+1)
+		case p.ordered:
+			mismatch.Err = fmt.Errorf("%w: row %d", ErrRowsMismatch, i+1)
+		default:
+			mismatch.Err = ErrRowsMismatch
+		}
+
+		return mismatch
+	}
+
+	return nil
+}
+
+// rowsEqual reports whether the rows of p are the same values, in any order
+// unless p is ordered, and the expected ones hold no matcher. It is checked
+// before preparing the matchers, which is much slower.
+func rowsEqual(p pair) bool {
+	expected, actual := p.expected, p.actual
+
+	if !p.ordered {
+		expected = cloneRows(expected)
+		actual = cloneRows(actual)
+
+		sortRows(expected)
+		sortRows(actual)
+	}
+
+	return slices.EqualFunc(expected, actual, func(e, a []string) bool {
+		return slices.Equal(e, a) && !slices.ContainsFunc(e, mayBeMatcher)
+	})
+}
+
+// matchesAnyRow reports whether actual matches one of the expected rows, so
+// that an ORDERED test only reports a row out of place when it is expected
+// elsewhere, rather than when one of its values is wrong.
+func matchesAnyRow(expected [][]string, actual []string) bool {
+	return slices.ContainsFunc(compileRows(expected), func(row expectedRow) bool {
+		return row.matches(actual)
+	})
+}
+
+// lineMismatch returns ErrDifferentColumnCount when the rows of line do not
+// have the same number of cells, or the error of their first matcher that does
+// not match, if any.
+func lineMismatch(line diffLine) error {
+	if len(line.expected) != len(line.actual) {
+		return ErrDifferentColumnCount
+	}
+
+	for i, expected := range line.expected {
+		if _, _, ok := parseMatcher(expected); !ok {
+			continue
+		}
+
+		if err := matchCell(expected, line.actual[i]); err != nil {
+			return fmt.Errorf("%w: column %d: %w", ErrRowsMismatch, i+1, err)
+		}
+	}
+
+	return nil
 }
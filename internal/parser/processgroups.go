@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 )
 
@@ -19,6 +20,13 @@ const (
 	instructionPrefixCount
 	instructionPrefixFile
 	instructionPrefixRow
+	instructionPrefixOrdered
+	instructionPrefixError
+	instructionPrefixAffected
+	instructionPrefixSetup
+	instructionPrefixTeardown
+	instructionPrefixBeforeEach
+	instructionPrefixAfterEach
 )
 
 func (ip instructionPrefix) String() string {
@@ -33,6 +41,20 @@ func (ip instructionPrefix) String() string {
 		return "FILE"
 	case instructionPrefixRow:
 		return "ROW"
+	case instructionPrefixOrdered:
+		return "ORDERED"
+	case instructionPrefixError:
+		return "ERROR"
+	case instructionPrefixAffected:
+		return "AFFECTED"
+	case instructionPrefixSetup:
+		return "SETUP"
+	case instructionPrefixTeardown:
+		return "TEARDOWN"
+	case instructionPrefixBeforeEach:
+		return "BEFORE_EACH"
+	case instructionPrefixAfterEach:
+		return "AFTER_EACH"
 	default:
 		return "UNKNOWN"
 	}
@@ -40,11 +62,18 @@ func (ip instructionPrefix) String() string {
 
 func buildMapPrefix() map[string]instructionPrefix {
 	return map[string]instructionPrefix{
-		"START_TEST": instructionPrefixStartTest,
-		"END_TEST":   instructionPrefixEndTest,
-		"COUNT":      instructionPrefixCount,
-		"FILE":       instructionPrefixFile,
-		"ROW":        instructionPrefixRow,
+		"START_TEST":  instructionPrefixStartTest,
+		"END_TEST":    instructionPrefixEndTest,
+		"COUNT":       instructionPrefixCount,
+		"FILE":        instructionPrefixFile,
+		"ROW":         instructionPrefixRow,
+		"ORDERED":     instructionPrefixOrdered,
+		"ERROR":       instructionPrefixError,
+		"AFFECTED":    instructionPrefixAffected,
+		"SETUP":       instructionPrefixSetup,
+		"TEARDOWN":    instructionPrefixTeardown,
+		"BEFORE_EACH": instructionPrefixBeforeEach,
+		"AFTER_EACH":  instructionPrefixAfterEach,
 	}
 }
 
@@ -68,11 +97,30 @@ func (ip instructionPrefix) Allowance() prefixAllowance {
 		return prefixAllowanceSingle
 	case instructionPrefixRow:
 		return prefixAllowanceMultiple
+	case instructionPrefixOrdered:
+		return prefixAllowanceSingle
+	case instructionPrefixError:
+		return prefixAllowanceSingle
+	case instructionPrefixAffected:
+		return prefixAllowanceSingle
+	case instructionPrefixSetup, instructionPrefixTeardown, instructionPrefixBeforeEach, instructionPrefixAfterEach:
+		return prefixAllowanceSingle
 	default:
 		return prefixAllowanceUnknown
 	}
 }
 
+// isFixture reports whether the instruction declares a statement that is run
+// around the tests instead of being tested.
+func (ip instructionPrefix) isFixture() bool {
+	switch ip {
+	case instructionPrefixSetup, instructionPrefixTeardown, instructionPrefixBeforeEach, instructionPrefixAfterEach:
+		return true
+	default:
+		return false
+	}
+}
+
 func checkCombinedInstructions(instrs []*outputInstruction) error {
 	uniquePrefixes := make(map[instructionPrefix]struct{})
 
@@ -88,6 +136,14 @@ var rgxInstructionPrefix = regexp.MustCompile(`^\s*(-{2,}|\/\*|\s*)*\s*([A-Z_]+)
 type outputInstruction struct {
 	_type  instructionPrefix
 	values [][]string
+	name   string
+	tags   []string
+	// ordered is set when the rows must be compared in the order they are returned.
+	ordered bool
+	// expectedErr is set by an ERROR instruction.
+	expectedErr *ExpectedError
+	// affected is set by an AFFECTED instruction.
+	affected *ExpectedAffected
 }
 
 func getInstructions(lines []parsedLine) (*outputInstruction, error) {
@@ -100,6 +156,12 @@ func getInstructions(lines []parsedLine) (*outputInstruction, error) {
 
 	uniquePrefixes := make(map[instructionPrefix]struct{})
 
+	var (
+		name    string
+		tags    []string
+		ordered bool
+	)
+
 	for _, pline := range lines {
 		prefixes := rgxInstructionPrefix.FindStringSubmatch(pline.line)
 
@@ -116,18 +178,26 @@ func getInstructions(lines []parsedLine) (*outputInstruction, error) {
 		}
 
 		if _, ok := uniquePrefixes[prefixType]; ok && prefixType.Allowance() == prefixAllowanceSingle {
-			return nil, fmt.Errorf("duplicate instruction: %s", prefixType)
+			return nil, atLine(pline.number, fmt.Errorf("duplicate instruction: %s", prefixType))
 		}
 
 		uniquePrefixes[prefixType] = struct{}{}
 
 		switch prefixType {
-		case instructionPrefixStartTest, instructionPrefixEndTest:
+		case instructionPrefixStartTest:
+			name, tags = extractNameAndTags(content)
+		case instructionPrefixEndTest:
 			continue
+		case instructionPrefixOrdered:
+			ordered = true
 		case instructionPrefixCount:
 			counts, err := extractCount(content)
 			if err != nil {
-				return nil, fmt.Errorf("unable to extract count: %w", err)
+				return nil, atLine(pline.number, fmt.Errorf("unable to extract count: %w", err))
+			}
+
+			if err := checkMatchers(counts); err != nil {
+				return nil, atLine(pline.number, err)
 			}
 
 			instrs = append(instrs, &outputInstruction{
@@ -138,7 +208,11 @@ func getInstructions(lines []parsedLine) (*outputInstruction, error) {
 		case instructionPrefixFile:
 			rows, err := extractFile(content)
 			if err != nil {
-				return nil, fmt.Errorf("unable to extract file: %w", err)
+				return nil, atLine(pline.number, fmt.Errorf("unable to extract file: %w", err))
+			}
+
+			if err := checkMatchers(rows); err != nil {
+				return nil, atLine(pline.number, err)
 			}
 
 			instrs = append(instrs, &outputInstruction{
@@ -149,13 +223,44 @@ func getInstructions(lines []parsedLine) (*outputInstruction, error) {
 		case instructionPrefixRow:
 			row, err := extractRow(content)
 			if err != nil {
-				return nil, fmt.Errorf("unable to extract row: %w", err)
+				return nil, atLine(pline.number, fmt.Errorf("unable to extract row: %w", err))
+			}
+
+			if err := checkMatchers([][]string{row}); err != nil {
+				return nil, atLine(pline.number, err)
 			}
 
 			rowsInstrs.values = append(rowsInstrs.values, row)
 
+		case instructionPrefixError:
+			expectedErr, err := extractError(content)
+			if err != nil {
+				return nil, atLine(pline.number, fmt.Errorf("unable to extract error: %w", err))
+			}
+
+			instrs = append(instrs, &outputInstruction{
+				_type:       prefixType,
+				expectedErr: expectedErr,
+			})
+
+		case instructionPrefixAffected:
+			affected, err := extractAffected(content)
+			if err != nil {
+				return nil, atLine(pline.number, fmt.Errorf("unable to extract affected rows: %w", err))
+			}
+
+			instrs = append(instrs, &outputInstruction{
+				_type:    prefixType,
+				affected: affected,
+			})
+
+		case instructionPrefixSetup, instructionPrefixTeardown, instructionPrefixBeforeEach, instructionPrefixAfterEach:
+			instrs = append(instrs, &outputInstruction{
+				_type: prefixType,
+			})
+
 		default:
-			return nil, fmt.Errorf("unknown instruction prefix: %s", prefix)
+			return nil, atLine(pline.number, fmt.Errorf("unknown instruction prefix: %s", prefix))
 		}
 	}
 
@@ -164,47 +269,81 @@ func getInstructions(lines []parsedLine) (*outputInstruction, error) {
 	}
 
 	if err := checkCombinedInstructions(instrs); err != nil {
-		return nil, fmt.Errorf("error checking combined instructions: %w", err)
+		return nil, atLine(lines[0].number, fmt.Errorf("error checking combined instructions: %w", err))
 	}
 
 	if len(instrs) == 0 {
-		return nil, fmt.Errorf("no instructions found")
+		return nil, atLine(lines[0].number, fmt.Errorf("no instructions found"))
 	}
 
 	if len(instrs) > 1 {
-		return nil, fmt.Errorf("multiple instructions found")
+		return nil, atLine(lines[0].number, fmt.Errorf("multiple instructions found"))
 	}
 
+	instrs[0].name = name
+	instrs[0].tags = tags
+	instrs[0].ordered = ordered
+
 	return instrs[0], nil
 }
 
 func checkValidUniquePrefixes(uniquePrefixes map[instructionPrefix]struct{}) error {
-	// Can't have any combinations of row, file or count instructions together
-	_, foundRow := uniquePrefixes[instructionPrefixRow]
-	_, foundFile := uniquePrefixes[instructionPrefixFile]
-	_, foundCount := uniquePrefixes[instructionPrefixCount]
-
-	if foundRow && foundFile && foundCount {
-		return fmt.Errorf("can't have both ROW, FILE and COUNT instructions")
+	// Can't have any combinations of row, file, count, error, affected or fixture instructions together
+	var found []string
+
+	for _, prefix := range []instructionPrefix{
+		instructionPrefixRow,
+		instructionPrefixFile,
+		instructionPrefixCount,
+		instructionPrefixError,
+		instructionPrefixAffected,
+		instructionPrefixSetup,
+		instructionPrefixTeardown,
+		instructionPrefixBeforeEach,
+		instructionPrefixAfterEach,
+	} {
+		if _, ok := uniquePrefixes[prefix]; ok {
+			found = append(found, prefix.String())
+		}
 	}
 
-	if foundRow && foundFile {
-		return fmt.Errorf("can't have both ROW and FILE instructions")
+	if len(found) > 1 {
+		return fmt.Errorf("can't have both %s and %s instructions", strings.Join(found[:len(found)-1], ", "), found[len(found)-1])
 	}
 
-	if foundRow && foundCount {
-		return fmt.Errorf("can't have both ROW and COUNT instructions")
-	}
+	return nil
+}
+
+// extractNameAndTags splits the content of a START_TEST line into the name of
+// the test and its @tag annotations.
+func extractNameAndTags(content string) (string, []string) {
+	content = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(content), "*/"))
+
+	var (
+		words []string
+		tags  []string
+	)
 
-	if foundFile && foundCount {
-		return fmt.Errorf("can't have both FILE and COUNT instructions")
+	for _, field := range strings.Fields(content) {
+		if tag, ok := strings.CutPrefix(field, "@"); ok {
+			if tag != "" {
+				tags = append(tags, tag)
+			}
+
+			continue
+		}
+
+		words = append(words, field)
 	}
 
-	return nil
+	return strings.Join(words, " "), tags
 }
 
+// extractCount splits the content of a COUNT instruction on spaces, except
+// the spaces between the parentheses of a matcher, such as
+// K_APPROX(3.14, 0.001).
 func extractCount(content string) ([][]string, error) {
-	content = strings.TrimSpace(content)
+	content, matchers := hideMatchers(strings.TrimSpace(content), isSpace)
 
 	splitted := strings.Fields(content)
 
@@ -215,14 +354,17 @@ func extractCount(content string) ([][]string, error) {
 	results := make([][]string, 0, len(splitted))
 
 	for _, s := range splitted {
-		results = append(results, []string{s})
+		results = append(results, []string{restoreMatchers(s, matchers)})
 	}
 
 	return results, nil
 }
 
+// extractRow splits the content of a ROW instruction as a CSV record, except
+// that the commas between the parentheses of a matcher starting a cell, such
+// as K_APPROX(3.14, 0.001), do not split it.
 func extractRow(content string) ([]string, error) {
-	content = strings.TrimSpace(content)
+	content, matchers := hideMatchers(strings.TrimSpace(content), isComma)
 
 	reader := csv.NewReader(strings.NewReader(content))
 	reader.LazyQuotes = true
@@ -234,15 +376,99 @@ func extractRow(content string) ([]string, error) {
 	}
 
 	results := make([]string, 0, len(record))
-	results = append(results, record...)
+
+	for _, cell := range record {
+		results = append(results, restoreMatchers(cell, matchers))
+	}
 
 	return results, nil
 }
 
+// hideMatchers replaces the matchers with arguments starting the cells of
+// content, which are split on the bytes for which isSeparator is true, with
+// placeholders, so that their separators and quotes are kept as they are. It
+// returns the replaced matchers, in order, see restoreMatchers.
+func hideMatchers(content string, isSeparator func(c byte) bool) (string, []string) {
+	var (
+		out      strings.Builder
+		matchers []string
+	)
+
+	cellStart := true
+
+	for i := 0; i < len(content); i++ {
+		if cellStart {
+			if end := matcherEnd(content[i:]); end != -1 {
+				out.WriteString(matcherPlaceholder(len(matchers)))
+				matchers = append(matchers, content[i:i+end])
+				i += end - 1
+				cellStart = false
+
+				continue
+			}
+		}
+
+		out.WriteByte(content[i])
+		cellStart = isSeparator(content[i])
+	}
+
+	return out.String(), matchers
+}
+
+// restoreMatchers replaces the placeholders of cell with the matchers hidden
+// by hideMatchers.
+func restoreMatchers(cell string, matchers []string) string {
+	for i, matcher := range matchers {
+		cell = strings.Replace(cell, matcherPlaceholder(i), matcher, 1)
+	}
+
+	return cell
+}
+
+func isComma(c byte) bool {
+	return c == ','
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t'
+}
+
+func matcherPlaceholder(i int) string {
+	return fmt.Sprintf("\x00%d\x00", i)
+}
+
+// rgxFileNull matches the NULL token that may follow the path of a FILE
+// instruction, e.g. FILE expected.csv NULL \N.
+var rgxFileNull = regexp.MustCompile(`^(.*?)\s+NULL\s+(\S+|"(?:[^"\\]|\\.)*")$`)
+
+// extractFile reads the expected rows from the CSV file following FILE. When
+// the path is followed by NULL <token>, the cells equal to the token, which
+// may be quoted, are NULLs. Unlike in ROW instructions, a cell holding a
+// matcher whose arguments contain a comma must be quoted as any CSV cell,
+// e.g. "K_APPROX(3.14, 0.001)".
 func extractFile(content string) ([][]string, error) {
 	content = strings.TrimSpace(content)
 
-	file, err := os.Open(filepath.Clean(content))
+	path := content
+	nullToken := ""
+	hasNullToken := false
+
+	if matches := rgxFileNull.FindStringSubmatch(content); matches != nil {
+		path = matches[1]
+		nullToken = matches[2]
+		hasNullToken = true
+
+		if strings.HasPrefix(nullToken, `"`) {
+			token, err := strconv.Unquote(nullToken)
+			if err != nil {
+				return nil, fmt.Errorf("invalid NULL token %s: %w", nullToken, err)
+			}
+
+			nullToken = token
+		}
+	}
+
+	file, err := os.Open(filepath.Clean(path))
 	if err != nil {
 		return nil, fmt.Errorf("unable to open file: %w", err)
 	}
@@ -263,6 +489,14 @@ func extractFile(content string) ([][]string, error) {
 			return nil, fmt.Errorf("error reading CSV content: %w", err)
 		}
 
+		if hasNullToken {
+			for i, cell := range record {
+				if cell == nullToken {
+					record[i] = string(KeywordNull)
+				}
+			}
+		}
+
 		results = append(results, record)
 	}
 
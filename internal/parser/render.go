@@ -0,0 +1,115 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// NullValue is how a NULL is rendered. Unlike psql's empty cell, it cannot be
+// mistaken for a text value, since Postgres text cannot contain a NUL byte, so
+// that only K_NULL matches it. Diffs display it as nullDisplay, and the JSON
+// reports as null.
+const NullValue = "\x00"
+
+// psqlTimestamptzFormat is how psql renders a timestamptz in the UTC time zone.
+const psqlTimestamptzFormat = "2006-01-02 15:04:05.999999-07"
+
+// renderRow renders the values of a row the way psql does, using the type of
+// their columns described by fields. raw holds the values as they were
+// received, which are used as they are when they are in the text format.
+func renderRow(typeMap *pgtype.Map, fields []pgconn.FieldDescription, values []any, raw [][]byte) []string {
+	row := make([]string, 0, len(values))
+
+	for i, v := range values {
+		var (
+			field pgconn.FieldDescription
+			src   []byte
+		)
+
+		if i < len(fields) {
+			field = fields[i]
+		}
+
+		if i < len(raw) {
+			src = raw[i]
+		}
+
+		row = append(row, renderValue(typeMap, field, v, src))
+	}
+
+	return row
+}
+
+// nullDisplay is how diffs display a NULL, like psql with \pset null, so
+// that it is not mistaken for an empty string.
+const nullDisplay = "∅"
+
+// displayRow returns row with its NULLs displayed as nullDisplay.
+func displayRow(row []string) []string {
+	if row == nil {
+		return nil
+	}
+
+	displayed := make([]string, len(row))
+
+	for i, cell := range row {
+		displayed[i] = cell
+		if cell == NullValue {
+			displayed[i] = nullDisplay
+		}
+	}
+
+	return displayed
+}
+
+// describeValue returns actual quoted, or NULL for a NULL, for error messages.
+func describeValue(actual string) string {
+	if actual == NullValue {
+		return "NULL"
+	}
+
+	return strconv.Quote(actual)
+}
+
+// renderValue renders v, a value of the column described by field. Values of
+// an unknown type, e.g. without a type OID, are rendered with fmt.
+func renderValue(typeMap *pgtype.Map, field pgconn.FieldDescription, v any, raw []byte) string {
+	if v == nil {
+		return NullValue
+	}
+
+	if field.DataTypeOID == 0 {
+		return fmt.Sprintf("%v", v)
+	}
+
+	if raw != nil {
+		switch {
+		case field.Format == pgtype.TextFormatCode:
+			return string(raw)
+		case field.DataTypeOID == pgtype.JSONOID:
+			return string(raw)
+		case field.DataTypeOID == pgtype.JSONBOID && len(raw) > 0:
+			// The binary format of jsonb is a version number followed by the text.
+			return string(raw[1:])
+		}
+	}
+
+	if t, ok := v.(time.Time); ok && field.DataTypeOID == pgtype.TimestamptzOID {
+		return t.UTC().Format(psqlTimestamptzFormat)
+	}
+
+	if _, ok := typeMap.TypeForOID(field.DataTypeOID); !ok {
+		return fmt.Sprintf("%v", v)
+	}
+
+	buf, err := typeMap.Encode(field.DataTypeOID, pgtype.TextFormatCode, v, nil)
+	if err != nil || buf == nil {
+		return fmt.Sprintf("%v", v)
+	}
+
+	return string(buf)
+}
@@ -0,0 +1,54 @@
+package parser
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderValue(t *testing.T) {
+	t.Parallel()
+
+	binary := func(oid uint32) pgconn.FieldDescription {
+		return pgconn.FieldDescription{DataTypeOID: oid, Format: pgtype.BinaryFormatCode}
+	}
+
+	testCases := map[string]struct {
+		field    pgconn.FieldDescription
+		value    any
+		raw      []byte
+		expected string
+	}{
+		"null":            {field: binary(pgtype.Int4OID), value: nil, expected: NullValue},
+		"bool":            {field: binary(pgtype.BoolOID), value: true, expected: "t"},
+		"int":             {field: binary(pgtype.Int8OID), value: int64(42), expected: "42"},
+		"numeric":         {field: binary(pgtype.NumericOID), value: pgtype.Numeric{Int: big.NewInt(1800), Exp: -2, Valid: true}, expected: "18.00"},
+		"float":           {field: binary(pgtype.Float8OID), value: 3.14, expected: "3.14"},
+		"text":            {field: binary(pgtype.TextOID), value: "coucou", expected: "coucou"},
+		"bytea":           {field: binary(pgtype.ByteaOID), value: []byte("ab"), expected: `\x6162`},
+		"int array":       {field: binary(pgtype.Int4ArrayOID), value: []any{int32(1), int32(2), nil}, expected: "{1,2,NULL}"},
+		"text array":      {field: binary(pgtype.TextArrayOID), value: []any{"a,b", "c"}, expected: `{"a,b",c}`},
+		"date":            {field: binary(pgtype.DateOID), value: time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC), expected: "2024-02-29"},
+		"timestamp":       {field: binary(pgtype.TimestampOID), value: time.Date(2024, 2, 29, 13, 4, 5, 120000000, time.UTC), expected: "2024-02-29 13:04:05.12"},
+		"timestamptz":     {field: binary(pgtype.TimestamptzOID), value: time.Date(2024, 2, 29, 14, 4, 5, 0, time.FixedZone("", 3600)), expected: "2024-02-29 13:04:05+00"},
+		"jsonb":           {field: binary(pgtype.JSONBOID), value: map[string]any{"a": 1.0}, raw: []byte("\x01{\"a\": 1}"), expected: `{"a": 1}`},
+		"text format":     {field: pgconn.FieldDescription{DataTypeOID: pgtype.BoolOID, Format: pgtype.TextFormatCode}, value: true, raw: []byte("t"), expected: "t"},
+		"unknown type":    {field: binary(0), value: true, expected: "true"},
+		"unregistered":    {field: binary(999999), value: "happy", expected: "happy"},
+		"uuid":            {field: binary(pgtype.UUIDOID), value: [16]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}, expected: "01020304-0506-0708-090a-0b0c0d0e0f10"},
+		"interval":        {field: binary(pgtype.IntervalOID), value: pgtype.Interval{Days: 1, Microseconds: 7200000000, Valid: true}, expected: "1 day 02:00:00"},
+		"negative number": {field: binary(pgtype.NumericOID), value: pgtype.Numeric{Int: big.NewInt(-5), Exp: 0, Valid: true}, expected: "-5"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tc.expected, renderValue(pgtype.NewMap(), tc.field, tc.value, tc.raw))
+		})
+	}
+}
@@ -2,9 +2,16 @@ package parser
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
 
 	"github.com/askiada/go-sql-test/internal/model"
 )
@@ -12,99 +19,262 @@ import (
 type pair struct {
 	expected [][]string
 	actual   [][]string
+	ordered  bool
 }
 
-func run(ctx context.Context, sqlFile string, db model.DB) ([]pair, error) {
-	lines, err := parseFile(sqlFile)
-	if err != nil {
-		return nil, ErrParseFile
+// Result is the outcome of a single test.
+type Result struct {
+	Test   *Test
+	Actual [][]string
+	// CommandTag is set when the statement was run through Exec.
+	CommandTag string
+	// PgError is set when the statement failed with a postgres error, even
+	// if it was expected to.
+	PgError  *pgconn.PgError
+	Duration time.Duration
+	Err      error
+}
+
+// Failed reports whether the test did not pass.
+func (r *Result) Failed() bool {
+	return r.Err != nil
+}
+
+// FileResult holds the results of every test of a file.
+type FileResult struct {
+	File     *File
+	Results  []*Result
+	Duration time.Duration
+	// Err is set when the file has mistakes, even if its well formed tests
+	// ran, or when its setup or teardown failed.
+	Err error
+}
+
+// Failed reports whether the file could not be parsed or any of its tests failed.
+func (fr *FileResult) Failed() bool {
+	return fr.Err != nil || fr.Failures() > 0
+}
+
+// RunFile parses filename and runs the tests selected by opts against db, in order.
+func RunFile(ctx context.Context, filename string, db model.DB, opts *Options) *FileResult {
+	start := time.Now()
+
+	file, err := ParseFile(filename)
+	if file == nil || (err != nil && opts.FailFast) {
+		return &FileResult{File: &File{Name: filename}, Err: err}
 	}
 
-	groups, err := getGroups(lines)
-	if err != nil {
-		return nil, fmt.Errorf("unable to get groups: %w", err)
+	fileRes := &FileResult{
+		File:    file,
+		Results: make([]*Result, 0, len(file.Tests)),
+		Err:     err,
 	}
 
-	for _, group := range groups {
-		fmt.Println(group)
+	if err = opts.Validate(); err != nil {
+		fileRes.Err = err
+
+		return fileRes
 	}
 
-	currPair := pair{}
+	runner := NewRunner(file, db, opts)
 
-	pairs := []pair{}
+	if err = runner.Setup(ctx); err != nil {
+		fileRes.Err = errors.Join(fileRes.Err, err)
+	} else {
+		fileRes.Results = runTests(ctx, runner, selectTests(file, opts), opts.Parallel, opts.FailFast)
+	}
 
-	for _, group := range groups {
-		switch group._type {
-		case groupTypeInstructions:
-			instr, err := getInstructions(group.lines)
-			if err != nil {
-				return nil, fmt.Errorf("unable to get instructions: %w", err)
-			}
+	if err = runner.Teardown(ctx); err != nil {
+		fileRes.Err = errors.Join(fileRes.Err, err)
+	}
 
-			if currPair.expected == nil {
-				currPair.expected = instr.values
-			} else {
-				return nil, ErrUnexpectedInstruction
-			}
+	fileRes.Duration = time.Since(start)
 
-			if currPair.actual != nil {
-				pairs = append(pairs, currPair)
-				currPair = pair{}
-			}
+	return fileRes
+}
 
-		case groupTypeStatement:
-			rebuildQuery := ""
-			for _, line := range group.lines {
-				rebuildQuery += line.line + "\n"
-			}
+func selectTests(file *File, opts *Options) []*Test {
+	tests := make([]*Test, 0, len(file.Tests))
 
-			rows, err := db.Query(ctx, rebuildQuery)
-			if err != nil {
-				return nil, fmt.Errorf("unable to query: %w", err)
-			}
+	for _, test := range file.Tests {
+		if opts.Selects(test) {
+			tests = append(tests, test)
+		}
+	}
 
-			res, err := processRows(rows)
-			if err != nil {
-				return nil, fmt.Errorf("unable to process rows: %w", err)
-			}
+	return tests
+}
 
-			if currPair.actual == nil {
-				currPair.actual = res
-			} else {
-				return nil, ErrUnexpectedStatement
+// runTests runs tests with at most parallel of them at the same time. The
+// results are in the same order as the tests. With failFast, no test is
+// started once one has failed, and only the results of the tests that ran
+// are returned.
+func runTests(ctx context.Context, runner *Runner, tests []*Test, parallel int, failFast bool) []*Result {
+	results := make([]*Result, len(tests))
+
+	if parallel <= 1 {
+		for i, test := range tests {
+			results[i] = runner.Run(ctx, test)
+
+			if failFast && results[i].Failed() {
+				return results[:i+1]
 			}
+		}
+
+		return results
+	}
+
+	var (
+		wg     sync.WaitGroup
+		failed atomic.Bool
+	)
+
+	sem := make(chan struct{}, parallel)
+
+	for i, test := range tests {
+		sem <- struct{}{}
 
-			if currPair.expected != nil {
-				pairs = append(pairs, currPair)
-				currPair = pair{}
+		if failFast && failed.Load() {
+			break
+		}
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			results[i] = runner.Run(ctx, test)
+			if results[i].Failed() {
+				failed.Store(true)
 			}
-		case groupTypeUnknown:
-			return nil, ErrUnexpectedGroupType
+
+			<-sem
+		}()
+	}
+
+	wg.Wait()
+
+	return slices.DeleteFunc(results, func(res *Result) bool { return res == nil })
+}
+
+// Failures returns the number of tests that failed.
+func (fr *FileResult) Failures() int {
+	failures := 0
+
+	for _, res := range fr.Results {
+		if res.Failed() {
+			failures++
 		}
 	}
 
-	return pairs, nil
+	return failures
 }
 
+// RunTest executes the statement of test against db and compares the rows it
+// returns with the expected ones, or the error it fails with with the expected
+// error.
+func RunTest(ctx context.Context, db model.DB, test *Test) *Result {
+	start := time.Now()
+
+	if test.Affected != nil {
+		return runExec(ctx, db, test, start)
+	}
+
+	actual, err := execute(ctx, db, test.SQL)
+
+	res := &Result{
+		Test:   test,
+		Actual: actual,
+	}
+
+	errors.As(err, &res.PgError)
+
+	switch {
+	case test.Error != nil:
+		err = test.Error.check(err)
+	case err == nil:
+		err = checkPair(pair{
+			expected: cloneRows(test.Expected),
+			actual:   cloneRows(actual),
+			ordered:  test.Ordered,
+		})
+	}
+
+	res.Duration = time.Since(start)
+	res.Err = err
+
+	return res
+}
+
+// runExec runs a test with an AFFECTED instruction.
+func runExec(ctx context.Context, db model.DB, test *Test, start time.Time) *Result {
+	res := &Result{
+		Test: test,
+	}
+
+	tag, err := db.Exec(ctx, test.SQL)
+	if err != nil {
+		errors.As(err, &res.PgError)
+
+		err = fmt.Errorf("unable to exec: %w", err)
+	} else {
+		res.CommandTag = tag.String()
+		err = test.Affected.check(tag)
+	}
+
+	res.Duration = time.Since(start)
+	res.Err = err
+
+	return res
+}
+
+func execute(ctx context.Context, db model.DB, query string) ([][]string, error) {
+	rows, err := db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query: %w", err)
+	}
+
+	res, err := processRows(rows)
+	if err != nil {
+		return nil, fmt.Errorf("unable to process rows: %w", err)
+	}
+
+	return res, nil
+}
+
+// processRows reads rows and renders their values the way psql does, see renderValue.
 func processRows(rows pgx.Rows) ([][]string, error) {
 	defer rows.Close()
 
 	res := [][]string{}
+	typeMap := pgtype.NewMap()
 
 	for rows.Next() {
-		rowAny, err := rows.Values()
+		values, err := rows.Values()
 		if err != nil {
 			return nil, fmt.Errorf("unable to get values: %w", err)
 		}
 
-		row := make([]string, 0, len(rowAny))
-
-		for _, v := range rowAny {
-			row = append(row, fmt.Sprintf("%v", v))
-		}
+		res = append(res, renderRow(typeMap, rows.FieldDescriptions(), values, rows.RawValues()))
+	}
 
-		res = append(res, row)
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read rows: %w", err)
 	}
 
 	return res, nil
 }
+
+func cloneRows(rows [][]string) [][]string {
+	if rows == nil {
+		return nil
+	}
+
+	res := make([][]string, 0, len(rows))
+
+	for _, row := range rows {
+		res = append(res, append([]string(nil), row...))
+	}
+
+	return res
+}
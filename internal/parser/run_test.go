@@ -2,8 +2,12 @@ package parser
 
 import (
 	"context"
+	"errors"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/pashagolub/pgxmock/v4"
 	"github.com/stretchr/testify/require"
 )
@@ -21,13 +25,12 @@ func TestRun1(t *testing.T) {
 
 	mock.ExpectQuery(".*").WillReturnRows(mock.NewRows([]string{"count"}).AddRow(int64(3)))
 
-	pairs, err := run(ctx, "testdata/1.sql", mock)
-	require.NoError(t, err)
+	fileRes := RunFile(ctx, "testdata/1.sql", mock, &Options{})
+	require.NoError(t, fileRes.Err)
+	require.NotEmpty(t, fileRes.Results)
 
-	for _, pair := range pairs {
-		pair, err := prepairPair(pair)
-		require.NoError(t, err)
-		require.Equal(t, pair.expected, pair.actual)
+	for _, res := range fileRes.Results {
+		require.NoError(t, res.Err)
 	}
 }
 
@@ -55,13 +58,12 @@ func TestRun2(t *testing.T) {
 	mrows2.AddRow("coucou2", false, 45, 18, "{'m': 'n'}")
 
 	mock.ExpectQuery(".*").WillReturnRows(mrows2)
-	pairs, err := run(ctx, "testdata/2.sql", mock)
-	require.NoError(t, err)
+	fileRes := RunFile(ctx, "testdata/2.sql", mock, &Options{})
+	require.NoError(t, fileRes.Err)
+	require.NotEmpty(t, fileRes.Results)
 
-	for _, pair := range pairs {
-		pair, err := prepairPair(pair)
-		require.NoError(t, err)
-		require.Equal(t, pair.expected, pair.actual)
+	for _, res := range fileRes.Results {
+		require.NoError(t, res.Err)
 	}
 }
 
@@ -89,12 +91,410 @@ func TestRun3(t *testing.T) {
 	mrows2.AddRow("coucou2", false, 45, 18, "{'m': 'n'}")
 
 	mock.ExpectQuery(".*").WillReturnRows(mrows2)
-	pairs, err := run(ctx, "testdata/3.sql", mock)
+	fileRes := RunFile(ctx, "testdata/3.sql", mock, &Options{})
+	require.NoError(t, fileRes.Err)
+	require.NotEmpty(t, fileRes.Results)
+
+	for _, res := range fileRes.Results {
+		require.NoError(t, res.Err)
+	}
+}
+
+func TestRunFile(t *testing.T) {
+	t.Parallel()
+
+	mock, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	ctx := context.Background()
+	defer mock.Close(ctx)
+
+	mrows := mock.NewRows([]string{"1", "2", "3", "4", "5"})
+
+	mrows.AddRow("coucou", true, 5, 3.14, "{'a': 'b'}")
+	mrows.AddRow("coucou2", false, 45, 18, "{'m': 'n'}")
+
+	mock.ExpectQuery(".*").WillReturnRows(mrows)
+
+	mrows2 := mock.NewRows([]string{"1", "2", "3", "4", "5"})
+
+	mrows2.AddRow("coucou", true, 5, 3.14, "{'a': 'b'}")
+
+	mock.ExpectQuery(".*").WillReturnRows(mrows2)
+
+	fileRes := RunFile(ctx, "testdata/2.sql", mock, &Options{})
+	require.NoError(t, fileRes.Err)
+	require.Len(t, fileRes.Results, 2)
+	require.NoError(t, fileRes.Results[0].Err)
+	require.ErrorIs(t, fileRes.Results[1].Err, ErrDifferentRowCount)
+	require.ErrorContains(t, fileRes.Results[1].Err, "- coucou2 | false | 45 | 18   | {'m': 'n'} |\n")
+	require.Equal(t, 1, fileRes.Failures())
+	require.True(t, fileRes.Failed())
+}
+
+func TestRunOrdered(t *testing.T) {
+	t.Parallel()
+
+	tcs := map[string]struct {
+		rows [][]any
+		err  error
+		msg  string
+	}{
+		"in order": {
+			rows: [][]any{{"alice", 10}, {"bob", 5}, {"carol", 1}},
+		},
+		"out of order": {
+			rows: [][]any{{"alice", 10}, {"carol", 1}, {"bob", 5}},
+			err:  ErrRowOutOfPlace,
+			msg:  "row 2 is out of place",
+		},
+		"changed value": {
+			rows: [][]any{{"alice", 10}, {"bob", 6}, {"carol", 1}},
+			err:  ErrRowsMismatch,
+			msg:  "actual rows do not match expected rows: row 2:",
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mock, err := pgxmock.NewConn()
+			require.NoError(t, err)
+
+			ctx := context.Background()
+			defer mock.Close(ctx)
+
+			mrows := mock.NewRows([]string{"customer", "total"})
+			for _, row := range tc.rows {
+				mrows.AddRow(row...)
+			}
+
+			mock.ExpectQuery(".*").WillReturnRows(mrows)
+
+			fileRes := RunFile(ctx, "testdata/5.sql", mock, &Options{})
+			require.NoError(t, fileRes.Err)
+			require.Len(t, fileRes.Results, 1)
+			require.True(t, fileRes.Results[0].Test.Ordered)
+
+			if tc.err == nil {
+				require.NoError(t, fileRes.Results[0].Err)
+
+				return
+			}
+
+			require.ErrorIs(t, fileRes.Results[0].Err, tc.err)
+			require.ErrorContains(t, fileRes.Results[0].Err, tc.msg)
+		})
+	}
+}
+
+func TestRunExpectedError(t *testing.T) {
+	t.Parallel()
+
+	duplicate := &pgconn.PgError{
+		Code:           "23505",
+		Message:        `duplicate key value violates unique constraint "users_email_key"`,
+		ConstraintName: "users_email_key",
+	}
+	check := &pgconn.PgError{
+		Code:           "23514",
+		Message:        `new row for relation "payments" violates check constraint "payments_amount_check"`,
+		ConstraintName: "payments_amount_check",
+	}
+
+	tcs := map[string]struct {
+		errs [2]error
+		want [2]error
+	}{
+		"expected errors": {
+			errs: [2]error{duplicate, check},
+		},
+		"wrong code and no error": {
+			errs: [2]error{check, nil},
+			want: [2]error{ErrUnexpectedError, ErrNoError},
+		},
+		"wrong constraint": {
+			errs: [2]error{&pgconn.PgError{Code: "23505", Message: duplicate.Message, ConstraintName: "users_pkey"}, check},
+			want: [2]error{ErrUnexpectedError, nil},
+		},
+		"wrong message": {
+			errs: [2]error{&pgconn.PgError{Code: "23505", ConstraintName: "users_email_key"}, check},
+			want: [2]error{ErrUnexpectedError, nil},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mock, err := pgxmock.NewConn()
+			require.NoError(t, err)
+
+			ctx := context.Background()
+			defer mock.Close(ctx)
+
+			for _, err := range tc.errs {
+				if err != nil {
+					mock.ExpectQuery(".*").WillReturnError(err)
+				} else {
+					mock.ExpectQuery(".*").WillReturnRows(mock.NewRows([]string{"id"}).AddRow(1))
+				}
+			}
+
+			fileRes := RunFile(ctx, "testdata/6.sql", mock, &Options{})
+			require.NoError(t, fileRes.Err)
+			require.Len(t, fileRes.Results, 2)
+
+			for i, want := range tc.want {
+				if want == nil {
+					require.NoError(t, fileRes.Results[i].Err)
+				} else {
+					require.ErrorIs(t, fileRes.Results[i].Err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRunAffected(t *testing.T) {
+	t.Parallel()
+
+	mock, err := pgxmock.NewConn()
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	defer mock.Close(ctx)
+
+	mock.ExpectExec("INSERT INTO orders").WillReturnResult(pgxmock.NewResult("INSERT 0", 3))
+	mock.ExpectExec("UPDATE orders").WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectExec("DELETE FROM orders").WillReturnResult(pgxmock.NewResult("DELETE", 3))
+
+	fileRes := RunFile(ctx, "testdata/7.sql", mock, &Options{})
+	require.NoError(t, fileRes.Err)
+	require.Len(t, fileRes.Results, 3)
+	require.NoError(t, fileRes.Results[0].Err)
+	require.Equal(t, "INSERT 0 3", fileRes.Results[0].CommandTag)
+	require.ErrorIs(t, fileRes.Results[1].Err, ErrRowsAffectedMismatch)
+	require.NoError(t, fileRes.Results[2].Err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRunFixtures(t *testing.T) {
+	t.Parallel()
+
+	mock, err := pgxmock.NewConn()
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	defer mock.Close(ctx)
+
+	mock.ExpectExec("CREATE TABLE orders").WillReturnResult(pgxmock.NewResult("CREATE TABLE", 0))
+	mock.ExpectExec("INSERT INTO orders").WillReturnResult(pgxmock.NewResult("INSERT 0", 2))
+	mock.ExpectQuery("SELECT COUNT").WillReturnRows(mock.NewRows([]string{"count"}).AddRow(int64(1)))
+	mock.ExpectExec("TRUNCATE orders").WillReturnResult(pgxmock.NewResult("TRUNCATE TABLE", 0))
+	mock.ExpectExec("INSERT INTO orders").WillReturnResult(pgxmock.NewResult("INSERT 0", 2))
+	mock.ExpectQuery("SELECT COUNT").WillReturnRows(mock.NewRows([]string{"count"}).AddRow(int64(1)))
+	mock.ExpectExec("TRUNCATE orders").WillReturnError(errors.New("boom"))
+	mock.ExpectExec("DROP TABLE orders").WillReturnResult(pgxmock.NewResult("DROP TABLE", 0))
+
+	fileRes := RunFile(ctx, "testdata/8.sql", mock, &Options{})
+	require.NoError(t, fileRes.Err)
+	require.Len(t, fileRes.Results, 2)
+	require.ErrorIs(t, fileRes.Results[0].Err, ErrRowsMismatch)
+	require.ErrorContains(t, fileRes.Results[1].Err, "testdata/8.sql:21: unable to run AFTER_EACH: boom")
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRunFixturesSetupFails(t *testing.T) {
+	t.Parallel()
+
+	mock, err := pgxmock.NewConn()
 	require.NoError(t, err)
 
-	for _, pair := range pairs {
-		pair, err := prepairPair(pair)
+	ctx := context.Background()
+	defer mock.Close(ctx)
+
+	mock.ExpectExec("CREATE TABLE orders").WillReturnError(errors.New("boom"))
+	mock.ExpectExec("DROP TABLE orders").WillReturnResult(pgxmock.NewResult("DROP TABLE", 0))
+
+	fileRes := RunFile(ctx, "testdata/8.sql", mock, &Options{})
+	require.EqualError(t, fileRes.Err, "testdata/8.sql:1: unable to run SETUP: boom")
+
+	var lineErr *LineError
+	require.ErrorAs(t, fileRes.Err, &lineErr)
+	require.Equal(t, 1, lineErr.Line)
+	require.Empty(t, fileRes.Results)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRunFileFailFast(t *testing.T) {
+	t.Parallel()
+
+	content := `-- START_TEST broken
+-- COUNT
+-- END_TEST
+SELECT 0
+
+-- START_TEST first
+-- COUNT 1
+-- END_TEST
+SELECT 1
+
+-- START_TEST second
+-- COUNT 2
+-- END_TEST
+SELECT 2
+`
+
+	filename := filepath.Join(t.TempDir(), "test.sql")
+	require.NoError(t, os.WriteFile(filename, []byte(content), 0o600))
+
+	ctx := context.Background()
+
+	t.Run("continue", func(t *testing.T) {
+		t.Parallel()
+
+		mock, err := pgxmock.NewConn()
+		require.NoError(t, err)
+
+		defer mock.Close(ctx)
+
+		mock.ExpectQuery("SELECT 1").WillReturnRows(mock.NewRows([]string{"count"}).AddRow(int64(5)))
+		mock.ExpectQuery("SELECT 2").WillReturnRows(mock.NewRows([]string{"count"}).AddRow(int64(2)))
+
+		fileRes := RunFile(ctx, filename, mock, &Options{})
+		require.ErrorContains(t, fileRes.Err, filename+":2: unable to get instructions: unable to extract count: empty content")
+		require.Len(t, fileRes.Results, 2)
+		require.ErrorIs(t, fileRes.Results[0].Err, ErrRowsMismatch)
+		require.NoError(t, fileRes.Results[1].Err)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("fail fast", func(t *testing.T) {
+		t.Parallel()
+
+		mock, err := pgxmock.NewConn()
+		require.NoError(t, err)
+
+		defer mock.Close(ctx)
+
+		fileRes := RunFile(ctx, filename, mock, &Options{FailFast: true})
+		require.Error(t, fileRes.Err)
+		require.Empty(t, fileRes.Results)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("fail fast tests", func(t *testing.T) {
+		t.Parallel()
+
+		mock, err := pgxmock.NewConn()
 		require.NoError(t, err)
-		require.Equal(t, pair.expected, pair.actual)
+
+		defer mock.Close(ctx)
+
+		mock.ExpectQuery("SELECT 1").WillReturnRows(mock.NewRows([]string{"count"}).AddRow(int64(5)))
+
+		file, _ := ParseFile(filename)
+		results := runTests(ctx, NewRunner(file, mock, &Options{}), file.Tests, 1, true)
+		require.Len(t, results, 1)
+		require.True(t, results[0].Failed())
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRunNull(t *testing.T) {
+	t.Parallel()
+
+	content := `-- START_TEST
+-- ROW 1,K_NULL
+-- ROW 2,
+-- END_TEST
+SELECT id, note FROM notes
+
+-- START_TEST
+-- ROW 1,K_ANY_NOT_NULL
+-- ROW 2,K_ANY_NOT_NULL
+-- END_TEST
+SELECT id, note FROM notes
+`
+
+	filename := filepath.Join(t.TempDir(), "test.sql")
+	require.NoError(t, os.WriteFile(filename, []byte(content), 0o600))
+
+	ctx := context.Background()
+
+	mock, err := pgxmock.NewConn()
+	require.NoError(t, err)
+
+	defer mock.Close(ctx)
+
+	for range 2 {
+		mock.ExpectQuery("SELECT id, note").WillReturnRows(mock.NewRows([]string{"id", "note"}).AddRow(int64(1), nil).AddRow(int64(2), ""))
 	}
+
+	fileRes := RunFile(ctx, filename, mock, &Options{})
+	require.NoError(t, fileRes.Err)
+	require.Len(t, fileRes.Results, 2)
+	require.NoError(t, fileRes.Results[0].Err)
+	require.ErrorIs(t, fileRes.Results[1].Err, ErrAnyNotNullButEmpty)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRunMatchers(t *testing.T) {
+	t.Parallel()
+
+	mock, err := pgxmock.NewConn()
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	defer mock.Close(ctx)
+
+	mock.ExpectQuery("SELECT COUNT").WillReturnRows(mock.NewRows([]string{"count"}).AddRow(int64(2)))
+	mock.ExpectQuery("SELECT id").WillReturnRows(mock.NewRows([]string{"id", "customer", "total", "note"}).
+		AddRow("0b0c0d0e-0506-0708-090a-0b0c0d0e0f10", "bob", "7.00", "").
+		AddRow("01020304-0506-0708-090a-0b0c0d0e0f10", "alice", "18.499", nil))
+	// K_ANY,K_ANY matches both rows, so it must leave 3,alice to the other row.
+	mock.ExpectQuery("SELECT code").WillReturnRows(mock.NewRows([]string{"code", "customer"}).
+		AddRow("x", "bob").
+		AddRow("3", "alice"))
+
+	fileRes := RunFile(ctx, "testdata/9.sql", mock, &Options{})
+	require.NoError(t, fileRes.Err)
+	require.Len(t, fileRes.Results, 3)
+
+	for _, res := range fileRes.Results {
+		require.NoError(t, res.Err, res.Test.Name)
+	}
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRunMatchersMismatch(t *testing.T) {
+	t.Parallel()
+
+	mock, err := pgxmock.NewConn()
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	defer mock.Close(ctx)
+
+	mock.ExpectQuery("SELECT COUNT").WillReturnRows(mock.NewRows([]string{"count"}).AddRow(int64(10)))
+	mock.ExpectQuery("SELECT id").WillReturnRows(mock.NewRows([]string{"id", "customer", "total", "note"}).
+		AddRow("0b0c0d0e-0506-0708-090a-0b0c0d0e0f10", "bob", "7.00", nil).
+		AddRow("01020304-0506-0708-090a-0b0c0d0e0f10", "alice", "18.6", ""))
+	mock.ExpectQuery("SELECT code").WillReturnRows(mock.NewRows([]string{"code", "customer"}).
+		AddRow("x", "bob").
+		AddRow("y", "alice"))
+
+	fileRes := RunFile(ctx, "testdata/9.sql", mock, &Options{})
+	require.NoError(t, fileRes.Err)
+	require.Len(t, fileRes.Results, 3)
+	require.ErrorIs(t, fileRes.Results[0].Err, ErrOutOfRange)
+	require.ErrorIs(t, fileRes.Results[1].Err, ErrRowsMismatch)
+	require.ErrorContains(t, fileRes.Results[1].Err, "! K_UUID | alice      | *K_APPROX(18.5, 0.01)* | *K_NULL* | 01020304-0506-0708-090a-0b0c0d0e0f10 | alice | *18.6* | **\n")
+	require.ErrorIs(t, fileRes.Results[2].Err, ErrNotAnInt)
+	require.NoError(t, mock.ExpectationsWereMet())
 }
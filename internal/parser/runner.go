@@ -0,0 +1,208 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/askiada/go-sql-test/internal/model"
+)
+
+// Runner runs the tests of a file with its fixtures, isolated as requested by
+// its options. Setup must be called first and Teardown last, even when Setup
+// fails. Run can be called concurrently, unless the isolation is
+// IsolationSavepoint.
+type Runner struct {
+	file *File
+	db   model.DB
+	opts *Options
+	// tx is the transaction holding the whole file with IsolationSavepoint.
+	tx pgx.Tx
+}
+
+// NewRunner returns a runner for the tests of file against db.
+func NewRunner(file *File, db model.DB, opts *Options) *Runner {
+	return &Runner{
+		file: file,
+		db:   db,
+		opts: opts,
+	}
+}
+
+// Setup runs the SETUP fixtures in order, and stops at the first one that
+// fails. With IsolationSavepoint, it first opens the transaction holding the
+// file.
+func (r *Runner) Setup(ctx context.Context) error {
+	if r.opts.Isolation == IsolationSavepoint {
+		tx, err := begin(ctx, r.db)
+		if err != nil {
+			return &LineError{File: r.file.Name, Err: err}
+		}
+
+		r.tx = tx
+	}
+
+	return runFixtures(ctx, r.conn(), r.file.Name, r.file.Setup, true)
+}
+
+// Teardown runs all the TEARDOWN fixtures, even when one of them fails or ctx
+// is cancelled. With IsolationSavepoint, it then rolls the file back. When
+// Setup could not open that transaction, nothing was run, so the fixtures are
+// skipped rather than run on the database itself.
+func (r *Runner) Teardown(ctx context.Context) error {
+	if r.opts.Isolation == IsolationSavepoint && r.tx == nil {
+		return nil
+	}
+
+	ctx = context.WithoutCancel(ctx)
+
+	err := runFixtures(ctx, r.conn(), r.file.Name, r.file.Teardown, false)
+
+	if r.tx != nil {
+		if rbErr := r.tx.Rollback(ctx); rbErr != nil {
+			err = errors.Join(err, &LineError{File: r.file.Name, Err: fmt.Errorf("unable to rollback file: %w", rbErr)})
+		}
+
+		r.tx = nil
+	}
+
+	return err
+}
+
+// Run is like RunTest but surrounds test with the BEFORE_EACH and AFTER_EACH
+// fixtures. The AFTER_EACH fixtures run even when test fails. Unless the
+// isolation is IsolationNone, everything is rolled back afterwards. The error
+// of a failed test is a *LineError at the first line of the test, joined with
+// the errors of the fixtures, each at the line of its fixture.
+func (r *Runner) Run(ctx context.Context, test *Test) *Result {
+	start := time.Now()
+
+	res := r.run(ctx, test)
+	res.Duration = time.Since(start)
+
+	return res
+}
+
+func (r *Runner) run(ctx context.Context, test *Test) *Result {
+	conn := r.conn()
+
+	if r.opts.Isolation == IsolationNone {
+		return runWithFixtures(ctx, conn, r.file, test, RunTest)
+	}
+
+	// Within the file transaction, Begin creates a savepoint.
+	tx, err := begin(ctx, conn)
+	if err != nil {
+		return &Result{Test: test, Err: atTest(r.file, test, err)}
+	}
+
+	res := runWithFixtures(ctx, tx, r.file, test, runInSavepoint)
+
+	if err := tx.Rollback(context.WithoutCancel(ctx)); err != nil {
+		res.Err = errors.Join(res.Err, atTest(r.file, test, fmt.Errorf("unable to rollback test: %w", err)))
+	}
+
+	return res
+}
+
+// atTest returns err as a *LineError at the first line of test.
+func atTest(file *File, test *Test, err error) error {
+	return &LineError{File: file.Name, Line: test.StartLine, Err: err}
+}
+
+// conn returns where the statements must run.
+func (r *Runner) conn() model.DB {
+	if r.tx != nil {
+		return r.tx
+	}
+
+	return r.db
+}
+
+type runTestFunc func(ctx context.Context, db model.DB, test *Test) *Result
+
+func runWithFixtures(ctx context.Context, db model.DB, file *File, test *Test, runTest runTestFunc) *Result {
+	var res *Result
+
+	if err := runFixtures(ctx, db, file.Name, file.BeforeEach, true); err != nil {
+		res = &Result{Test: test, Err: err}
+	} else {
+		res = runTest(ctx, db, test)
+
+		if res.Err != nil {
+			res.Err = atTest(file, test, res.Err)
+		}
+	}
+
+	if err := runFixtures(context.WithoutCancel(ctx), db, file.Name, file.AfterEach, false); err != nil {
+		res.Err = errors.Join(res.Err, err)
+	}
+
+	return res
+}
+
+// runInSavepoint runs test in its own savepoint within a transaction, so that
+// a failed statement, expected or not, does not abort the transaction for the
+// AFTER_EACH fixtures, which would then fail and hide the error of the test.
+// The savepoint is released when the test passes, so that AFTER_EACH sees its
+// changes, and rolled back otherwise.
+func runInSavepoint(ctx context.Context, db model.DB, test *Test) *Result {
+	savepoint, err := begin(ctx, db)
+	if err != nil {
+		return &Result{Test: test, Err: err}
+	}
+
+	res := RunTest(ctx, savepoint, test)
+
+	if res.Err == nil && test.Error == nil {
+		if err := savepoint.Commit(ctx); err != nil {
+			res.Err = fmt.Errorf("unable to release savepoint: %w", err)
+		}
+
+		return res
+	}
+
+	if err := savepoint.Rollback(context.WithoutCancel(ctx)); err != nil {
+		res.Err = errors.Join(res.Err, fmt.Errorf("unable to rollback savepoint: %w", err))
+	}
+
+	return res
+}
+
+func begin(ctx context.Context, db model.DB) (pgx.Tx, error) { //nolint:ireturn // pgx returns an interface
+	beginner, ok := db.(model.TxBeginner)
+	if !ok {
+		return nil, ErrNoTransaction
+	}
+
+	tx, err := beginner.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to begin transaction: %w", err)
+	}
+
+	return tx, nil
+}
+
+// runFixtures runs fixtures, the ones of filename, and returns their errors
+// as *LineError at the line of their fixture.
+func runFixtures(ctx context.Context, db model.DB, filename string, fixtures []*Fixture, stopOnError bool) error {
+	var errs []error
+
+	for _, fixture := range fixtures {
+		_, err := db.Exec(ctx, fixture.SQL)
+		if err == nil {
+			continue
+		}
+
+		errs = append(errs, &LineError{File: filename, Line: fixture.Line, Err: fmt.Errorf("unable to run %s: %w", fixture.Kind, err)})
+
+		if stopOnError {
+			break
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,194 @@
+package parser
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/require"
+
+	"github.com/askiada/go-sql-test/internal/model"
+)
+
+func TestRunnerIsolationSavepoint(t *testing.T) {
+	t.Parallel()
+
+	mock, err := pgxmock.NewConn()
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	defer mock.Close(ctx)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("CREATE TABLE orders").WillReturnResult(pgxmock.NewResult("CREATE TABLE", 0))
+
+	for i := range 2 {
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO orders").WillReturnResult(pgxmock.NewResult("INSERT 0", 2))
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT COUNT").WillReturnRows(mock.NewRows([]string{"count"}).AddRow(int64(2)))
+
+		// The savepoint of the test is released when it passes.
+		if i == 0 {
+			mock.ExpectCommit()
+		} else {
+			mock.ExpectRollback()
+		}
+
+		mock.ExpectExec("TRUNCATE orders").WillReturnResult(pgxmock.NewResult("TRUNCATE TABLE", 0))
+		mock.ExpectRollback()
+	}
+
+	mock.ExpectExec("DROP TABLE orders").WillReturnResult(pgxmock.NewResult("DROP TABLE", 0))
+	mock.ExpectRollback()
+
+	fileRes := RunFile(ctx, "testdata/8.sql", mock, &Options{Isolation: IsolationSavepoint})
+	require.NoError(t, fileRes.Err)
+	require.Len(t, fileRes.Results, 2)
+	require.NoError(t, fileRes.Results[0].Err)
+	require.ErrorIs(t, fileRes.Results[1].Err, ErrRowsMismatch)
+	require.ErrorContains(t, fileRes.Results[1].Err, "testdata/8.sql:16: ")
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRunnerIsolationTransactionExpectedError(t *testing.T) {
+	t.Parallel()
+
+	mock, err := pgxmock.NewConn()
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	defer mock.Close(ctx)
+
+	for _, code := range []string{"23505", "23514"} {
+		mock.ExpectBegin()
+		mock.ExpectBegin()
+		mock.ExpectQuery("INSERT INTO").WillReturnError(&pgconn.PgError{
+			Code:           code,
+			Message:        "duplicate key value",
+			ConstraintName: "users_email_key",
+		})
+		mock.ExpectRollback()
+		mock.ExpectRollback()
+	}
+
+	fileRes := RunFile(ctx, "testdata/6.sql", mock, &Options{Isolation: IsolationTransaction})
+	require.NoError(t, fileRes.Err)
+	require.Len(t, fileRes.Results, 2)
+	require.NoError(t, fileRes.Results[0].Err)
+	require.NoError(t, fileRes.Results[1].Err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRunnerIsolationTransactionUnexpectedError(t *testing.T) {
+	t.Parallel()
+
+	mock, err := pgxmock.NewConn()
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	defer mock.Close(ctx)
+
+	mock.ExpectExec("CREATE TABLE orders").WillReturnResult(pgxmock.NewResult("CREATE TABLE", 0))
+
+	for _, queryErr := range []error{&pgconn.PgError{Code: "42P01", Message: `relation "orders" does not exist`}, nil} {
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO orders").WillReturnResult(pgxmock.NewResult("INSERT 0", 2))
+		mock.ExpectBegin()
+
+		if queryErr != nil {
+			mock.ExpectQuery("SELECT COUNT").WillReturnError(queryErr)
+		} else {
+			mock.ExpectQuery("SELECT COUNT").WillReturnRows(mock.NewRows([]string{"count"}).AddRow(int64(1)))
+		}
+
+		// The failed statement is rolled back to its savepoint before
+		// AFTER_EACH, which would fail in an aborted transaction.
+		if queryErr != nil {
+			mock.ExpectRollback()
+		} else {
+			mock.ExpectCommit()
+		}
+
+		mock.ExpectExec("TRUNCATE orders").WillReturnResult(pgxmock.NewResult("TRUNCATE TABLE", 0))
+		mock.ExpectRollback()
+	}
+
+	mock.ExpectExec("DROP TABLE orders").WillReturnResult(pgxmock.NewResult("DROP TABLE", 0))
+
+	fileRes := RunFile(ctx, "testdata/8.sql", mock, &Options{Isolation: IsolationTransaction})
+	require.NoError(t, fileRes.Err)
+	require.Len(t, fileRes.Results, 2)
+	require.ErrorContains(t, fileRes.Results[0].Err, `relation "orders" does not exist`)
+	require.NotContains(t, fileRes.Results[0].Err.Error(), "AFTER_EACH")
+	require.NoError(t, fileRes.Results[1].Err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRunnerIsolationNotSupported(t *testing.T) {
+	t.Parallel()
+
+	mock, err := pgxmock.NewConn()
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	defer mock.Close(ctx)
+
+	// Hide the Begin method of the mock.
+	var db model.DB = struct{ model.DB }{mock}
+
+	fileRes := RunFile(ctx, "testdata/5.sql", db, &Options{Isolation: IsolationTransaction})
+	require.NoError(t, fileRes.Err)
+	require.Len(t, fileRes.Results, 1)
+	require.ErrorIs(t, fileRes.Results[0].Err, ErrNoTransaction)
+}
+
+func TestRunnerIsolationSavepointNotSupported(t *testing.T) {
+	t.Parallel()
+
+	mock, err := pgxmock.NewConn()
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	defer mock.Close(ctx)
+
+	// Neither SETUP nor TEARDOWN runs outside of the file transaction.
+	var db model.DB = struct{ model.DB }{mock}
+
+	fileRes := RunFile(ctx, "testdata/8.sql", db, &Options{Isolation: IsolationSavepoint})
+	require.ErrorIs(t, fileRes.Err, ErrNoTransaction)
+	require.NotContains(t, fileRes.Err.Error(), "TEARDOWN")
+	require.Empty(t, fileRes.Results)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRunFileParallelTests(t *testing.T) {
+	t.Parallel()
+
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	defer mock.Close()
+
+	mock.MatchExpectationsInOrder(false)
+	mock.ExpectQuery("FROM orders GROUP BY").WillReturnRows(mock.NewRows([]string{"customer", "count"}).AddRow("bob", 1).AddRow("alice", 2))
+	mock.ExpectQuery("FROM orders$").WillReturnRows(mock.NewRows([]string{"count"}).AddRow(int64(3)))
+	mock.ExpectQuery("FROM refunds").WillReturnRows(mock.NewRows([]string{"count"}).AddRow(int64(1)))
+
+	fileRes := RunFile(ctx, "testdata/4.sql", mock, &Options{Parallel: 3})
+	require.NoError(t, fileRes.Err)
+	require.Len(t, fileRes.Results, 3)
+
+	for i, res := range fileRes.Results {
+		require.Equal(t, i+1, res.Test.Index)
+	}
+
+	require.NoError(t, fileRes.Results[0].Err)
+	require.NoError(t, fileRes.Results[1].Err)
+	require.ErrorIs(t, fileRes.Results[2].Err, ErrRowsMismatch)
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	require.ErrorIs(t, RunFile(ctx, "testdata/4.sql", mock, &Options{Parallel: 2, Isolation: IsolationSavepoint}).Err, ErrParallelSavepoint)
+}
@@ -1,17 +1,24 @@
 package parser
 
-import "sort"
+import "slices"
 
 func sortRows(rows [][]string) {
-	sort.Slice(rows, func(i, j int) bool {
-		// Iterate over each string in the inner slices for comparison
-		for k := 0; k < len(rows[i]) && k < len(rows[j]); k++ {
-			if rows[i][k] != rows[j][k] {
-				// If strings differ, use them to determine order
-				return rows[i][k] < rows[j][k]
+	slices.SortFunc(rows, compareRows)
+}
+
+// compareRows orders rows cell by cell, a row that is a prefix of another one
+// coming first.
+func compareRows(a, b []string) int {
+	for k := 0; k < len(a) && k < len(b); k++ {
+		if a[k] != b[k] {
+			// If strings differ, use them to determine order
+			if a[k] < b[k] {
+				return -1
 			}
+
+			return 1
 		}
-		// If all compared strings are equal, the shorter slice comes first
-		return len(rows[i]) < len(rows[j])
-	})
+	}
+	// If all compared strings are equal, the shorter slice comes first
+	return len(a) - len(b)
 }
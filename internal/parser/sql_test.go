@@ -2,6 +2,7 @@ package parser
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -13,6 +14,10 @@ import (
 
 func TestRunSQL(t *testing.T) { //nolint:paralleltest // This test is not parallel
 	env, err := getEnv()
+	if errors.Is(err, ErrNoDBHost) {
+		t.Skip("DB_HOST is not set")
+	}
+
 	require.NoError(t, err)
 
 	ctx := context.Background()
@@ -24,12 +29,10 @@ func TestRunSQL(t *testing.T) { //nolint:paralleltest // This test is not parall
 	}, 1)
 
 	require.NoError(t, err)
-	pairs, err := run(ctx, env.sqlFile, pool.DBConnection)
-	require.NoError(t, err)
+	fileRes := RunFile(ctx, env.sqlFile, pool.DBConnection, &Options{})
+	require.NoError(t, fileRes.Err)
 
-	for _, pair := range pairs {
-		pair, err := prepairPair(pair)
-		require.NoError(t, err)
-		require.Equal(t, pair.expected, pair.actual)
+	for _, res := range fileRes.Results {
+		require.NoError(t, res.Err)
 	}
 }
@@ -0,0 +1,17 @@
+-- START_TEST orders_total @smoke
+/*
+COUNT 3
+*/
+-- END_TEST
+SELECT COUNT(*) FROM orders
+
+/* START_TEST orders_by_customer @slow @reporting
+ROW "alice",2
+ROW "bob",1
+END_TEST */
+SELECT customer, COUNT(*) FROM orders GROUP BY customer
+
+-- START_TEST
+-- COUNT 0
+-- END_TEST
+SELECT COUNT(*) FROM refunds
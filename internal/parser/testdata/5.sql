@@ -0,0 +1,9 @@
+-- START_TEST top_customers
+/*
+ORDERED
+ROW "alice",10
+ROW "bob",5
+ROW "carol",1
+*/
+-- END_TEST
+SELECT customer, total FROM totals ORDER BY total DESC
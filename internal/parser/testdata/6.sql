@@ -0,0 +1,9 @@
+-- START_TEST duplicate_email
+-- ERROR 23505 constraint=users_email_key ^duplicate key value
+-- END_TEST
+INSERT INTO users (email) VALUES ('alice@example.com') RETURNING id
+
+-- START_TEST negative_amount
+-- ERROR 23
+-- END_TEST
+INSERT INTO payments (amount) VALUES (-1) RETURNING id
@@ -0,0 +1,14 @@
+-- START_TEST insert_orders
+-- AFFECTED 3 INSERT 0 3
+-- END_TEST
+INSERT INTO orders (customer) VALUES ('alice'), ('bob'), ('carol')
+
+-- START_TEST archive_orders
+-- AFFECTED 2
+-- END_TEST
+UPDATE orders SET archived = true WHERE customer <> 'alice'
+
+-- START_TEST purge_orders
+-- AFFECTED DELETE 3
+-- END_TEST
+DELETE FROM orders
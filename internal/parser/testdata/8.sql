@@ -0,0 +1,29 @@
+-- START_TEST
+-- SETUP
+-- END_TEST
+CREATE TABLE orders (id serial PRIMARY KEY, customer text NOT NULL);
+
+-- START_TEST
+-- BEFORE_EACH
+-- END_TEST
+INSERT INTO orders (customer) VALUES ('alice'), ('bob');
+
+-- START_TEST count_orders
+-- COUNT 2
+-- END_TEST
+SELECT COUNT(*) FROM orders
+
+-- START_TEST count_alice
+-- COUNT 1
+-- END_TEST
+SELECT COUNT(*) FROM orders WHERE customer = 'alice'
+
+-- START_TEST
+-- AFTER_EACH
+-- END_TEST
+TRUNCATE orders;
+
+-- START_TEST
+-- TEARDOWN
+-- END_TEST
+DROP TABLE orders;
@@ -0,0 +1,15 @@
+-- START_TEST count_matchers
+-- COUNT K_INT(>=1, <10)
+-- END_TEST
+SELECT COUNT(*) FROM orders
+
+-- START_TEST file_matchers
+-- FILE testdata/9.csv NULL \N
+-- END_TEST
+SELECT id, customer, total, note FROM orders
+
+-- START_TEST unordered_matchers
+-- ROW K_ANY,K_ANY
+-- ROW K_INT(>0),K_LIKE(a%)
+-- END_TEST
+SELECT code, customer FROM orders
@@ -0,0 +1,10 @@
+package report
+
+type reportError string
+
+func (s reportError) Error() string {
+	return string(s)
+}
+
+// ErrUnknownKind is returned when a kind of report does not exist.
+const ErrUnknownKind = reportError("unknown kind of report")
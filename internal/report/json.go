@@ -0,0 +1,234 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/askiada/go-sql-test/internal/parser"
+)
+
+// jsonTest is the record of a test. A file that cannot be parsed, or whose
+// setup or teardown fails, gets a record without index with status "error".
+type jsonTest struct {
+	File       string       `json:"file"`
+	Index      int          `json:"index,omitempty"`
+	Name       string       `json:"name,omitempty"`
+	Tags       []string     `json:"tags,omitempty"`
+	StartLine  int          `json:"start_line,omitempty"`
+	EndLine    int          `json:"end_line,omitempty"`
+	SQL        string       `json:"sql,omitempty"`
+	Mode       string       `json:"mode,omitempty"`
+	Expected   [][]string   `json:"expected,omitempty"`
+	Actual     [][]*string  `json:"actual,omitempty"`
+	CommandTag string       `json:"command_tag,omitempty"`
+	Status     string       `json:"status"`
+	DurationMS float64      `json:"duration_ms"`
+	Error      string       `json:"error,omitempty"`
+	PgError    *jsonPgError `json:"pg_error,omitempty"`
+}
+
+type jsonPgError struct {
+	Severity       string `json:"severity,omitempty"`
+	Code           string `json:"code"`
+	Message        string `json:"message"`
+	Detail         string `json:"detail,omitempty"`
+	Hint           string `json:"hint,omitempty"`
+	Position       int32  `json:"position,omitempty"`
+	Where          string `json:"where,omitempty"`
+	SchemaName     string `json:"schema_name,omitempty"`
+	TableName      string `json:"table_name,omitempty"`
+	ColumnName     string `json:"column_name,omitempty"`
+	DataTypeName   string `json:"data_type_name,omitempty"`
+	ConstraintName string `json:"constraint_name,omitempty"`
+	Routine        string `json:"routine,omitempty"`
+}
+
+type jsonFile struct {
+	File       string      `json:"file"`
+	Status     string      `json:"status"`
+	DurationMS float64     `json:"duration_ms"`
+	Error      string      `json:"error,omitempty"`
+	Tests      []*jsonTest `json:"tests"`
+}
+
+type jsonReport struct {
+	Files []*jsonFile `json:"files"`
+}
+
+// jsonReporter writes a single JSON document once all the files are done.
+type jsonReporter struct {
+	w io.Writer
+}
+
+func (r *jsonReporter) File(*parser.FileResult) error {
+	return nil
+}
+
+func (r *jsonReporter) End(results []*parser.FileResult) error {
+	return JSON(r.w, results)
+}
+
+// jsonLinesReporter writes a JSON record per test as soon as its file is done.
+type jsonLinesReporter struct {
+	w io.Writer
+}
+
+func (r *jsonLinesReporter) File(fileRes *parser.FileResult) error {
+	return JSONLines(r.w, fileRes)
+}
+
+func (r *jsonLinesReporter) End([]*parser.FileResult) error {
+	return nil
+}
+
+// JSON writes results to w as a JSON document.
+func JSON(w io.Writer, results []*parser.FileResult) error {
+	doc := jsonReport{
+		Files: make([]*jsonFile, 0, len(results)),
+	}
+
+	for _, fileRes := range results {
+		file := &jsonFile{
+			File:       fileRes.File.Name,
+			Status:     fileStatus(fileRes),
+			DurationMS: milliseconds(fileRes.Duration),
+			Tests:      make([]*jsonTest, 0, len(fileRes.Results)),
+		}
+
+		if fileRes.Err != nil {
+			file.Error = fileRes.Err.Error()
+		}
+
+		for _, res := range fileRes.Results {
+			file.Tests = append(file.Tests, newJSONTest(fileRes.File, res))
+		}
+
+		doc.Files = append(doc.Files, file)
+	}
+
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to marshal json report: %w", err)
+	}
+
+	return write(w, "json report", string(out)+"\n")
+}
+
+// JSONLines writes a JSON record per test of fileRes to w, one per line.
+func JSONLines(w io.Writer, fileRes *parser.FileResult) error {
+	records := make([]*jsonTest, 0, len(fileRes.Results)+1)
+
+	for _, res := range fileRes.Results {
+		records = append(records, newJSONTest(fileRes.File, res))
+	}
+
+	if fileRes.Err != nil {
+		records = append(records, &jsonTest{
+			File:       fileRes.File.Name,
+			Status:     "error",
+			DurationMS: milliseconds(fileRes.Duration),
+			Error:      fileRes.Err.Error(),
+		})
+	}
+
+	out := strings.Builder{}
+	enc := json.NewEncoder(&out)
+
+	for _, record := range records {
+		if err := enc.Encode(record); err != nil {
+			return fmt.Errorf("unable to marshal json record: %w", err)
+		}
+	}
+
+	return write(w, "json lines report", out.String())
+}
+
+func newJSONTest(file *parser.File, res *parser.Result) *jsonTest {
+	record := &jsonTest{
+		File:       file.Name,
+		Index:      res.Test.Index,
+		Name:       res.Test.Name,
+		Tags:       res.Test.Tags,
+		StartLine:  res.Test.StartLine,
+		EndLine:    res.Test.EndLine,
+		SQL:        res.Test.SQL,
+		Mode:       res.Test.Mode(),
+		Expected:   res.Test.Expected,
+		Actual:     jsonRows(res.Actual),
+		CommandTag: res.CommandTag,
+		Status:     "pass",
+		DurationMS: milliseconds(res.Duration),
+		PgError:    newJSONPgError(res.PgError),
+	}
+
+	if res.Failed() {
+		record.Status = "fail"
+		record.Error = res.Err.Error()
+	}
+
+	return record
+}
+
+func newJSONPgError(pgErr *pgconn.PgError) *jsonPgError {
+	if pgErr == nil {
+		return nil
+	}
+
+	return &jsonPgError{
+		Severity:       pgErr.Severity,
+		Code:           pgErr.Code,
+		Message:        pgErr.Message,
+		Detail:         pgErr.Detail,
+		Hint:           pgErr.Hint,
+		Position:       pgErr.Position,
+		Where:          pgErr.Where,
+		SchemaName:     pgErr.SchemaName,
+		TableName:      pgErr.TableName,
+		ColumnName:     pgErr.ColumnName,
+		DataTypeName:   pgErr.DataTypeName,
+		ConstraintName: pgErr.ConstraintName,
+		Routine:        pgErr.Routine,
+	}
+}
+
+func fileStatus(fileRes *parser.FileResult) string {
+	switch {
+	case fileRes.Err != nil:
+		return "error"
+	case fileRes.Failed():
+		return "fail"
+	default:
+		return "pass"
+	}
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// jsonRows returns rows with their NULLs as JSON nulls, so that they are not
+// mistaken for empty strings.
+func jsonRows(rows [][]string) [][]*string {
+	if rows == nil {
+		return nil
+	}
+
+	converted := make([][]*string, len(rows))
+
+	for i, row := range rows {
+		converted[i] = make([]*string, len(row))
+
+		for j := range row {
+			if row[j] != parser.NullValue {
+				converted[i][j] = &row[j]
+			}
+		}
+	}
+
+	return converted
+}
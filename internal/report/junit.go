@@ -0,0 +1,130 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/askiada/go-sql-test/internal/parser"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Body    string `xml:",cdata"`
+}
+
+// junitReporter writes a JUnit XML document with a test suite per file and a
+// test case per test. A file that cannot be parsed, or whose setup or
+// teardown fails, gets an extra test case with an error.
+type junitReporter struct {
+	w io.Writer
+}
+
+func (r *junitReporter) File(*parser.FileResult) error {
+	return nil
+}
+
+func (r *junitReporter) End(results []*parser.FileResult) error {
+	return JUnit(r.w, results)
+}
+
+// JUnit writes results to w as a JUnit XML document.
+func JUnit(w io.Writer, results []*parser.FileResult) error {
+	doc := junitTestSuites{}
+
+	var total time.Duration
+
+	for _, fileRes := range results {
+		suite := junitTestSuite{
+			Name:     fileRes.File.Name,
+			Tests:    len(fileRes.Results),
+			Failures: fileRes.Failures(),
+			Time:     junitTime(fileRes.Duration),
+		}
+
+		for _, res := range fileRes.Results {
+			testCase := junitTestCase{
+				Name:      res.Test.DisplayName(),
+				ClassName: fileRes.File.Name,
+				Time:      junitTime(res.Duration),
+			}
+
+			if res.Failed() {
+				testCase.Failure = &junitProblem{
+					Message: firstLine(res.Err.Error()),
+					Body:    res.Err.Error() + "\n\nSQL:\n" + strings.TrimRight(res.Test.SQL, "\n"),
+				}
+			}
+
+			suite.Cases = append(suite.Cases, testCase)
+		}
+
+		if fileRes.Err != nil {
+			suite.Tests++
+			suite.Errors++
+			suite.Cases = append(suite.Cases, junitTestCase{
+				Name:      fileRes.File.Name,
+				ClassName: fileRes.File.Name,
+				Time:      junitTime(0),
+				Error: &junitProblem{
+					Message: firstLine(fileRes.Err.Error()),
+					Body:    fileRes.Err.Error(),
+				},
+			})
+		}
+
+		doc.Tests += suite.Tests
+		doc.Failures += suite.Failures
+		doc.Errors += suite.Errors
+		total += fileRes.Duration
+
+		doc.Suites = append(doc.Suites, suite)
+	}
+
+	doc.Time = junitTime(total)
+
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to marshal junit report: %w", err)
+	}
+
+	return write(w, "junit report", xml.Header+string(out)+"\n")
+}
+
+func junitTime(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+
+	return strings.TrimSuffix(line, ":")
+}
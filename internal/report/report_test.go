@@ -0,0 +1,154 @@
+package report
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+
+	"github.com/askiada/go-sql-test/internal/parser"
+)
+
+func testResults() []*parser.FileResult {
+	ordersTotal := &parser.Test{
+		Index: 1, Name: "orders_total", Tags: []string{"smoke"}, StartLine: 1, EndLine: 4,
+		SQL: "SELECT COUNT(*) FROM orders\n", Expected: [][]string{{"3"}},
+	}
+	byCustomer := &parser.Test{
+		Index: 2, StartLine: 6, EndLine: 9,
+		SQL: "SELECT customer FROM orders\n", Expected: [][]string{{"alice"}},
+	}
+	duplicate := &parser.Test{
+		Index: 3, Name: "duplicate", StartLine: 11, EndLine: 14,
+		SQL: "INSERT INTO orders (id) VALUES (1)\n", Affected: &parser.ExpectedAffected{Rows: 1},
+	}
+
+	return []*parser.FileResult{
+		{
+			File: &parser.File{Name: "orders.sql", Tests: []*parser.Test{ordersTotal, byCustomer, duplicate}},
+			Results: []*parser.Result{
+				{Test: ordersTotal, Actual: [][]string{{"3"}}, Duration: 2 * time.Millisecond},
+				{
+					Test:     byCustomer,
+					Actual:   [][]string{{"bob"}, {parser.NullValue}},
+					Duration: 3 * time.Millisecond,
+					Err:      errors.New("actual rows do not match expected rows:\nexpected: [[alice]]\nactual:   [[bob]]"),
+				},
+				{
+					Test:     duplicate,
+					PgError:  &pgconn.PgError{Code: "23505", Message: "duplicate key value", ConstraintName: "orders_pkey"},
+					Duration: time.Millisecond,
+					Err:      errors.New("unable to exec: duplicate key value (SQLSTATE 23505)"),
+				},
+			},
+			Duration: 6 * time.Millisecond,
+		},
+		{
+			File:     &parser.File{Name: "broken.sql"},
+			Duration: time.Millisecond,
+			Err:      errors.New("unable to get groups: unexpected end of group inside statement group"),
+		},
+	}
+}
+
+func TestJUnit(t *testing.T) {
+	t.Parallel()
+
+	out := &bytes.Buffer{}
+
+	err := JUnit(out, testResults())
+	require.NoError(t, err)
+
+	expected, err := os.ReadFile("testdata/report.xml")
+	require.NoError(t, err)
+	require.Equal(t, string(expected), out.String())
+}
+
+func TestJSONLines(t *testing.T) {
+	t.Parallel()
+
+	out := &bytes.Buffer{}
+
+	for _, fileRes := range testResults() {
+		err := JSONLines(out, fileRes)
+		require.NoError(t, err)
+	}
+
+	expected, err := os.ReadFile("testdata/report.jsonl")
+	require.NoError(t, err)
+	require.Equal(t, string(expected), out.String())
+}
+
+func TestJSON(t *testing.T) {
+	t.Parallel()
+
+	out := &bytes.Buffer{}
+
+	err := JSON(out, testResults())
+	require.NoError(t, err)
+
+	expected, err := os.ReadFile("testdata/report.json")
+	require.NoError(t, err)
+	require.Equal(t, string(expected), out.String())
+}
+
+func TestTAP(t *testing.T) {
+	t.Parallel()
+
+	out := &bytes.Buffer{}
+
+	reporter, err := New("tap", out)
+	require.NoError(t, err)
+
+	results := testResults()
+	for _, fileRes := range results {
+		require.NoError(t, reporter.File(fileRes))
+	}
+
+	require.NoError(t, reporter.End(results))
+
+	expected, err := os.ReadFile("testdata/report.tap")
+	require.NoError(t, err)
+	require.Equal(t, string(expected), out.String())
+}
+
+func TestTAPWithoutResults(t *testing.T) {
+	t.Parallel()
+
+	out := &bytes.Buffer{}
+
+	reporter, err := New("tap", out)
+	require.NoError(t, err)
+
+	// The tests of the first files were all filtered out.
+	results := append([]*parser.FileResult{
+		{File: &parser.File{Name: "empty.sql"}},
+		{File: &parser.File{Name: "filtered.sql"}},
+	}, testResults()...)
+
+	for _, fileRes := range results {
+		require.NoError(t, reporter.File(fileRes))
+	}
+
+	require.NoError(t, reporter.End(results))
+
+	expected, err := os.ReadFile("testdata/report.tap")
+	require.NoError(t, err)
+	require.Equal(t, string(expected), out.String())
+
+	out.Reset()
+
+	reporter, err = New("tap", out)
+	require.NoError(t, err)
+
+	for _, fileRes := range results[:2] {
+		require.NoError(t, reporter.File(fileRes))
+	}
+
+	require.NoError(t, reporter.End(results[:2]))
+	require.Equal(t, "TAP version 13\n1..0\n", out.String())
+}
@@ -0,0 +1,53 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/askiada/go-sql-test/internal/parser"
+)
+
+// Reporter writes the results of the files.
+type Reporter interface {
+	// File is called with the result of every file, in order, as soon as it
+	// is available.
+	File(fileRes *parser.FileResult) error
+	// End is called once all the files are done.
+	End(results []*parser.FileResult) error
+}
+
+// Kinds returns the kinds of reports New can create.
+func Kinds() []string {
+	return []string{"text", "junit", "json", "jsonl", "tap"}
+}
+
+// New returns the reporter of the given kind, writing to w.
+func New(kind string, w io.Writer) (Reporter, error) { //nolint:ireturn // the kind decides the implementation
+	switch kind {
+	case "text":
+		return &textReporter{w: w}, nil
+	case "junit":
+		return &junitReporter{w: w}, nil
+	case "json":
+		return &jsonReporter{w: w}, nil
+	case "jsonl":
+		return &jsonLinesReporter{w: w}, nil
+	case "tap":
+		return &tapReporter{w: w}, nil
+	default:
+		return nil, fmt.Errorf("%w: %q, expected one of %s", ErrUnknownKind, kind, strings.Join(Kinds(), ", "))
+	}
+}
+
+type textReporter struct {
+	w io.Writer
+}
+
+func (r *textReporter) File(fileRes *parser.FileResult) error {
+	return Text(r.w, fileRes)
+}
+
+func (r *textReporter) End(results []*parser.FileResult) error {
+	return Summary(r.w, results)
+}
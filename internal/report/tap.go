@@ -0,0 +1,111 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/askiada/go-sql-test/internal/parser"
+)
+
+// tapReporter writes a TAP version 13 stream, with a test point per test and
+// a YAML diagnostic block for the failures. The plan is written at the end,
+// since the number of tests is only known once all the files are done.
+type tapReporter struct {
+	w     io.Writer
+	count int
+	// started is set once the header is written, which may be before any test
+	// point when the first files have no results.
+	started bool
+}
+
+func (r *tapReporter) File(fileRes *parser.FileResult) error {
+	out := strings.Builder{}
+
+	r.writeHeader(&out)
+
+	for _, res := range fileRes.Results {
+		r.count++
+
+		description := fmt.Sprintf("%s %s", fileRes.File.Name, testLabel(res.Test))
+
+		if !res.Failed() {
+			out.WriteString(fmt.Sprintf("ok %d - %s\n", r.count, description))
+
+			continue
+		}
+
+		out.WriteString(fmt.Sprintf("not ok %d - %s\n", r.count, description))
+		writeTAPDiagnostic(&out, []tapField{
+			{"message", strconv.Quote(firstLine(res.Err.Error()))},
+			{"severity", "fail"},
+			{"file", strconv.Quote(fileRes.File.Name)},
+			{"line", strconv.Itoa(res.Test.StartLine)},
+			{"duration_ms", strconv.FormatFloat(milliseconds(res.Duration), 'f', -1, 64)},
+			{"error", tapBlock(res.Err.Error())},
+			{"sql", tapBlock(res.Test.SQL)},
+		})
+	}
+
+	if fileRes.Err != nil {
+		r.count++
+
+		out.WriteString(fmt.Sprintf("not ok %d - %s\n", r.count, fileRes.File.Name))
+		writeTAPDiagnostic(&out, []tapField{
+			{"message", strconv.Quote(firstLine(fileRes.Err.Error()))},
+			{"severity", "error"},
+			{"file", strconv.Quote(fileRes.File.Name)},
+			{"error", tapBlock(fileRes.Err.Error())},
+		})
+	}
+
+	return write(r.w, "tap report", out.String())
+}
+
+func (r *tapReporter) End([]*parser.FileResult) error {
+	out := strings.Builder{}
+
+	r.writeHeader(&out)
+	out.WriteString(fmt.Sprintf("1..%d\n", r.count))
+
+	return write(r.w, "tap report", out.String())
+}
+
+func (r *tapReporter) writeHeader(out *strings.Builder) {
+	if r.started {
+		return
+	}
+
+	out.WriteString("TAP version 13\n")
+
+	r.started = true
+}
+
+type tapField struct {
+	key   string
+	value string
+}
+
+func writeTAPDiagnostic(out *strings.Builder, fields []tapField) {
+	out.WriteString("  ---\n")
+
+	for _, field := range fields {
+		out.WriteString(fmt.Sprintf("  %s: %s\n", field.key, field.value))
+	}
+
+	out.WriteString("  ...\n")
+}
+
+// tapBlock returns s as a YAML literal block, indented for a diagnostic.
+func tapBlock(s string) string {
+	block := strings.Builder{}
+	block.WriteString("|-")
+
+	for _, line := range strings.Split(strings.TrimRight(s, "\n"), "\n") {
+		block.WriteString("\n    ")
+		block.WriteString(line)
+	}
+
+	return block.String()
+}
@@ -0,0 +1,93 @@
+// Package report writes test results in the formats understood by humans and CI tools.
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/askiada/go-sql-test/internal/parser"
+)
+
+// Text writes a human-readable report of fileRes to w: one line per test,
+// followed by a line for the file itself.
+func Text(w io.Writer, fileRes *parser.FileResult) error {
+	out := strings.Builder{}
+
+	for _, res := range fileRes.Results {
+		status := "PASS"
+		if res.Failed() {
+			status = "FAIL"
+		}
+
+		out.WriteString(fmt.Sprintf("%s %s %s (%s)\n", status, fileRes.File.Name, testLabel(res.Test), res.Duration))
+
+		if res.Failed() {
+			writeIndented(&out, res.Err.Error())
+			writeIndented(&out, "SQL:\n"+strings.TrimRight(res.Test.SQL, "\n"))
+		}
+	}
+
+	switch {
+	case fileRes.Err != nil:
+		out.WriteString(fmt.Sprintf("FAIL %s\n", fileRes.File.Name))
+		writeIndented(&out, fileRes.Err.Error())
+	case fileRes.Failed():
+		out.WriteString(fmt.Sprintf("FAIL %s (%d of %d tests failed, %s)\n",
+			fileRes.File.Name, fileRes.Failures(), len(fileRes.Results), fileRes.Duration))
+	default:
+		out.WriteString(fmt.Sprintf("ok   %s (%d tests, %s)\n", fileRes.File.Name, len(fileRes.Results), fileRes.Duration))
+	}
+
+	return write(w, "text report", out.String())
+}
+
+// Summary writes the overall number of passed and failed files and tests.
+func Summary(w io.Writer, results []*parser.FileResult) error {
+	var filesFailed, testsPassed, testsFailed int
+
+	for _, fileRes := range results {
+		if fileRes.Failed() {
+			filesFailed++
+		}
+
+		testsFailed += fileRes.Failures()
+		testsPassed += len(fileRes.Results) - fileRes.Failures()
+	}
+
+	status := "PASS"
+	if filesFailed > 0 {
+		status = "FAIL"
+	}
+
+	summary := fmt.Sprintf("\n%s files: %d passed, %d failed; tests: %d passed, %d failed\n",
+		status, len(results)-filesFailed, filesFailed, testsPassed, testsFailed)
+
+	return write(w, "summary", summary)
+}
+
+// testLabel returns #<index> followed by the name of the test, if any.
+func testLabel(test *parser.Test) string {
+	if test.Name == "" {
+		return fmt.Sprintf("#%d", test.Index)
+	}
+
+	return fmt.Sprintf("#%d %s", test.Index, test.Name)
+}
+
+func write(w io.Writer, what, s string) error {
+	_, err := io.WriteString(w, s)
+	if err != nil {
+		return fmt.Errorf("unable to write %s: %w", what, err)
+	}
+
+	return nil
+}
+
+func writeIndented(out *strings.Builder, s string) {
+	for _, line := range strings.Split(s, "\n") {
+		out.WriteString("    ")
+		out.WriteString(line)
+		out.WriteString("\n")
+	}
+}
@@ -1,30 +1,19 @@
+// Command go-sql-test runs SQL test files against a PostgreSQL database.
 package main
 
 import (
-	"fmt"
+	"context"
 	"os"
-	"os/exec"
-	"syscall"
+	"os/signal"
+
+	"github.com/askiada/go-sql-test/internal/cli"
 )
 
 func main() {
-	// Prepare the go test command
-	// You can specify a particular package or file by adding arguments to the command
-	cmd := exec.Command("go", "test", "github.com/askiada/go-sql-test/internal/parser", "-run", "TestRunSQL")
-
-	// Run the command and capture the output and error
-	output, err := cmd.CombinedOutput()
-	fmt.Println(string(output)) //nolint:forbidigo // Print the output before exiting
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
 
-	if err != nil {
-		// Check if the error is an ExitError
-		if exitErr, ok := err.(*exec.ExitError); ok { //nolint:errorlint // error is never wrapped
-			// Check if the underlying system call error is available and extract the exit code
-			if status, ok := exitErr.Sys().(syscall.WaitStatus); ok {
-				os.Exit(status.ExitStatus())
-			}
-		}
+	code := cli.Main(ctx, os.Args[1:], os.Stdout, os.Stderr)
 
-		os.Exit(1) // Default exit code for general errors
-	}
+	stop()
+	os.Exit(code)
 }
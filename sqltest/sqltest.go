@@ -0,0 +1,175 @@
+// Package sqltest runs SQL test files from Go tests.
+//
+// Every START_TEST/END_TEST block of a file becomes a subtest, named after the
+// text following START_TEST, so a single assertion can be targeted with
+// go test -run and its failure is reported under its own name:
+//
+//	func TestSQL(t *testing.T) {
+//		sqltest.Run(t, pool, "testdata/orders.sql", sqltest.WithTags("!slow"))
+//	}
+//
+// Values that need a bespoke comparison can be checked by custom matchers,
+// registered once, e.g. from TestMain:
+//
+//	sqltest.RegisterMatcher("COUNTRY", func(actual string, _ []string) error {
+//		if !countries[actual] {
+//			return fmt.Errorf("%q is not an ISO country code", actual)
+//		}
+//
+//		return nil
+//	})
+//
+// and then used as K_COUNTRY in ROW, FILE and COUNT instructions.
+package sqltest
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/askiada/go-sql-test/internal/model"
+	"github.com/askiada/go-sql-test/internal/parser"
+)
+
+// DB is the interface that can perform database-related queries. It is
+// satisfied by *pgxpool.Pool, *pgx.Conn and pgx.Tx.
+type DB = model.DB
+
+// MatchFunc checks the actual value of a cell against a K_<NAME>(args)
+// expected cell, and returns an error explaining why it does not match.
+// actual is the value as psql renders it, or Null. args are the trimmed
+// arguments between the parentheses, split on the commas that are neither
+// quoted nor nested in brackets; an argument between single quotes is
+// unquoted.
+type MatchFunc = parser.MatchFunc
+
+// Null is the actual value a MatchFunc gets for a NULL. It cannot be the value
+// of a text column, which cannot contain a NUL byte.
+const Null = parser.NullValue
+
+// RegisterMatcher makes K_<name>(args) usable in the expected cells of ROW,
+// FILE and COUNT instructions, checked by match. The matchers are shared by
+// all the tests, so it should be called before they run. It returns an error
+// if name is not made of upper case letters, digits and underscores, or is
+// already registered, including by a built-in matcher such as K_ANY.
+func RegisterMatcher(name string, match MatchFunc) error {
+	return parser.RegisterMatcher(name, match) //nolint:wrapcheck // the error already names the matcher
+}
+
+// Option configures how the tests of a file are run.
+type Option func(opts *parser.Options)
+
+// WithRun only runs the tests whose name matches rgx. Unnamed tests are
+// named test_<index>.
+func WithRun(rgx *regexp.Regexp) Option {
+	return func(opts *parser.Options) {
+		opts.Run = rgx
+	}
+}
+
+// WithTags only runs the tests that have at least one of tags, and none of
+// the tags prefixed with "!".
+func WithTags(tags ...string) Option {
+	return func(opts *parser.Options) {
+		opts.Tags = append(opts.Tags, tags...)
+	}
+}
+
+// WithFailFast stops at the first failure: a file with a mistake fails the
+// test before any statement is run, and the subtests after a failed one are
+// skipped. By default, the mistakes of the file are reported and its well
+// formed tests still run.
+func WithFailFast() Option {
+	return func(opts *parser.Options) {
+		opts.FailFast = true
+	}
+}
+
+// Isolation tells how the tests are isolated from each other.
+type Isolation = parser.Isolation
+
+const (
+	// IsolationNone runs the statements as they are, so every test sees the
+	// changes of the ones before.
+	IsolationNone = parser.IsolationNone
+	// IsolationTransaction runs every test in a transaction that is rolled
+	// back. db must be able to begin transactions.
+	IsolationTransaction = parser.IsolationTransaction
+	// IsolationSavepoint runs the whole file in a transaction, and every test
+	// in a savepoint, that are rolled back. db must be able to begin
+	// transactions.
+	IsolationSavepoint = parser.IsolationSavepoint
+)
+
+// WithIsolation rolls back the changes made by the tests, as described by
+// isolation.
+func WithIsolation(isolation Isolation) Option {
+	return func(opts *parser.Options) {
+		opts.Isolation = isolation
+	}
+}
+
+// Run parses filename and runs each of its tests against db as a subtest of t.
+// The tests run in the order they appear in the file, after the SETUP
+// fixtures and surrounded by the BEFORE_EACH and AFTER_EACH ones. The
+// TEARDOWN fixtures run when t and its subtests complete.
+func Run(t *testing.T, db DB, filename string, opts ...Option) {
+	t.Helper()
+
+	RunContext(context.Background(), t, db, filename, opts...)
+}
+
+// RunContext is like Run but executes the statements with ctx.
+func RunContext(ctx context.Context, t *testing.T, db DB, filename string, opts ...Option) {
+	t.Helper()
+
+	options := &parser.Options{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	file, err := parser.ParseFile(filename)
+	if file == nil || (err != nil && options.FailFast) {
+		t.Fatal(err)
+	}
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	runner := parser.NewRunner(file, db, options)
+
+	t.Cleanup(func() {
+		if err := runner.Teardown(ctx); err != nil {
+			t.Error(err)
+		}
+	})
+
+	if err := runner.Setup(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	failed := false
+
+	for _, test := range file.Tests {
+		if !options.Selects(test) {
+			continue
+		}
+
+		t.Run(test.DisplayName(), func(t *testing.T) {
+			t.Helper()
+
+			if failed {
+				t.Skip("skipped after a failure")
+			}
+
+			res := runner.Run(ctx, test)
+			if res.Failed() {
+				failed = options.FailFast
+
+				t.Errorf("%s\nSQL:\n%s", res.Err, strings.TrimRight(test.SQL, "\n"))
+			}
+		})
+	}
+}
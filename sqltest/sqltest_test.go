@@ -0,0 +1,99 @@
+package sqltest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pashagolub/pgxmock/v4"
+
+	"github.com/askiada/go-sql-test/internal/parser"
+	"github.com/askiada/go-sql-test/sqltest"
+)
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	mock, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	ctx := context.Background()
+	defer mock.Close(ctx)
+
+	for range 2 {
+		mrows := mock.NewRows([]string{"1", "2", "3", "4", "5"})
+
+		mrows.AddRow("coucou", true, 5, 3.14, "{'a': 'b'}")
+		mrows.AddRow("coucou2", false, 45, 18, "{'m': 'n'}")
+
+		mock.ExpectQuery(".*").WillReturnRows(mrows)
+	}
+
+	sqltest.Run(t, mock, "../internal/parser/testdata/3.sql")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRegisterMatcher(t *testing.T) {
+	t.Parallel()
+
+	err := sqltest.RegisterMatcher("K_TEST_PREFIX", func(actual string, args []string) error {
+		if len(args) != 1 {
+			return errors.New("expected a prefix")
+		}
+
+		if !strings.HasPrefix(actual, args[0]) {
+			return fmt.Errorf("%q does not start with %q", actual, args[0])
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { parser.UnregisterMatcher("TEST_PREFIX") })
+
+	if err := sqltest.RegisterMatcher("TEST_PREFIX", nil); err == nil {
+		t.Error("a matcher was registered twice")
+	}
+
+	if err := sqltest.RegisterMatcher("ANY", nil); err == nil {
+		t.Error("a built-in matcher was replaced")
+	}
+
+	filename := filepath.Join(t.TempDir(), "test.sql")
+
+	content := `-- START_TEST
+-- ROW 1,K_TEST_PREFIX(coucou)
+-- END_TEST
+SELECT id, name FROM users
+`
+	if err := os.WriteFile(filename, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	mock, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	ctx := context.Background()
+	defer mock.Close(ctx)
+
+	mock.ExpectQuery(".*").WillReturnRows(mock.NewRows([]string{"id", "name"}).AddRow(1, "coucou2"))
+
+	sqltest.Run(t, mock, filename)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}